@@ -0,0 +1,67 @@
+package api
+
+import (
+	"io"
+	"net/http"
+)
+
+// MaskService manages random (relay) and custom domain masks.
+type MaskService interface {
+	ListRelayAddresses() ([]RelayAddress, error)
+	GetRelayAddress(id int) (*RelayAddress, error)
+	CreateRelayAddress(req CreateRelayAddressRequest) (*RelayAddress, error)
+	UpdateRelayAddress(id int, req UpdateRelayAddressRequest) (*RelayAddress, error)
+	DeleteRelayAddress(id int) error
+	ListDomainAddresses() ([]DomainAddress, error)
+	GetDomainAddress(id int) (*DomainAddress, error)
+	CreateDomainAddress(req CreateDomainAddressRequest) (*DomainAddress, error)
+	UpdateDomainAddress(id int, req UpdateDomainAddressRequest) (*DomainAddress, error)
+	DeleteDomainAddress(id int) error
+}
+
+// PhoneService manages the Relay phone number mask and the real phone it
+// forwards to.
+type PhoneService interface {
+	ListRelayNumbers() ([]RelayNumber, error)
+	GetRelayNumberSuggestions() (*RelayNumberSuggestions, error)
+	SearchRelayNumbers(areaCode string) ([]PhoneNumberOption, error)
+	UpdateRelayNumber(id int, req UpdateRelayNumberRequest) (*RelayNumber, error)
+	GetRealPhone() ([]RealPhone, error)
+	RegisterRealPhone(req RegisterRealPhoneRequest) (*RealPhone, error)
+	VerifyRealPhone(id int, req VerifyRealPhoneRequest) (*RealPhone, error)
+	DeleteRealPhone(id int) error
+}
+
+// ContactService manages the contacts that have called or texted the
+// phone mask.
+type ContactService interface {
+	ListInboundContacts() ([]InboundContact, error)
+	UpdateInboundContact(id int, req UpdateInboundContactRequest) (*InboundContact, error)
+}
+
+// ProfileService reads the account's profile and user records.
+type ProfileService interface {
+	GetProfiles() ([]Profile, error)
+	ListUsers() ([]User, error)
+}
+
+// RelayAPI is the full set of Firefox Relay operations used by ffrelayctl.
+// *Client is the HTTP implementation; fakes, caches or multi-account
+// wrappers can be substituted by implementing the same methods.
+type RelayAPI interface {
+	MaskService
+	PhoneService
+	ContactService
+	ProfileService
+}
+
+var _ RelayAPI = (*Client)(nil)
+
+// Requester is implemented by clients that can send arbitrary authenticated
+// requests, such as *Client.
+type Requester interface {
+	NewRequest(method, path string, body io.Reader) (*http.Request, error)
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var _ Requester = (*Client)(nil)
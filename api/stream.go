@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Streamer is implemented by clients that can decode list responses one item
+// at a time, so callers can start processing before the whole list arrives.
+type Streamer interface {
+	StreamRelayAddresses(fn func(RelayAddress) error) error
+	StreamDomainAddresses(fn func(DomainAddress) error) error
+	StreamRelayNumbers(fn func(RelayNumber) error) error
+	StreamInboundContacts(fn func(InboundContact) error) error
+}
+
+var _ Streamer = (*Client)(nil)
+
+func (c *Client) StreamRelayAddresses(fn func(RelayAddress) error) error {
+	return streamList(c, relayAddressesPath, fn)
+}
+
+func (c *Client) StreamDomainAddresses(fn func(DomainAddress) error) error {
+	return streamList(c, domainAddressesPath, fn)
+}
+
+func (c *Client) StreamRelayNumbers(fn func(RelayNumber) error) error {
+	return streamList(c, relayNumbersPath, fn)
+}
+
+func (c *Client) StreamInboundContacts(fn func(InboundContact) error) error {
+	return streamList(c, inboundContactsPath, fn)
+}
+
+func streamList[T any](c *Client, path string, fn func(T) error) error {
+	resp, err := c.Get(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return decodeArray(resp.Body, fn)
+}
+
+// decodeArray calls fn for each element of the JSON array read from r,
+// decoding elements as they arrive.
+func decodeArray[T any](r io.Reader, fn func(T) error) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array, got %v", tok)
+	}
+
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+	return err
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_StreamRelayAddresses(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("test")
+
+	t.Run("streams each address", func(t *testing.T) {
+		httpmock.RegisterResponder(http.MethodGet, DefaultBaseURL+relayAddressesPath,
+			httpmock.NewStringResponder(http.StatusOK, `[
+				{"id": 1, "full_address": "a@mozmail.com"},
+				{"id": 2, "full_address": "b@mozmail.com"}
+			]`))
+
+		var ids []int
+		err := client.StreamRelayAddresses(func(a RelayAddress) error {
+			ids = append(ids, a.ID)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, ids)
+	})
+
+	t.Run("callback error stops streaming", func(t *testing.T) {
+		httpmock.RegisterResponder(http.MethodGet, DefaultBaseURL+relayAddressesPath,
+			httpmock.NewStringResponder(http.StatusOK, `[{"id": 1}, {"id": 2}]`))
+
+		stop := errors.New("stop")
+		calls := 0
+		err := client.StreamRelayAddresses(func(a RelayAddress) error {
+			calls++
+			return stop
+		})
+
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		httpmock.RegisterResponder(http.MethodGet, DefaultBaseURL+relayAddressesPath,
+			httpmock.NewStringResponder(http.StatusUnauthorized, `{"detail": "Invalid token."}`))
+
+		err := client.StreamRelayAddresses(func(a RelayAddress) error { return nil })
+
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	})
+}
+
+func TestClient_StreamOtherLists(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient("test")
+	httpmock.RegisterResponder(http.MethodGet, DefaultBaseURL+domainAddressesPath,
+		httpmock.NewStringResponder(http.StatusOK, `[{"id": 3}]`))
+	httpmock.RegisterResponder(http.MethodGet, DefaultBaseURL+relayNumbersPath,
+		httpmock.NewStringResponder(http.StatusOK, `[{"id": 4}]`))
+	httpmock.RegisterResponder(http.MethodGet, DefaultBaseURL+inboundContactsPath,
+		httpmock.NewStringResponder(http.StatusOK, `[{"id": 5}, {"id": 6}]`))
+
+	var domainIDs, numberIDs, contactIDs []int
+	require.NoError(t, client.StreamDomainAddresses(func(a DomainAddress) error {
+		domainIDs = append(domainIDs, a.ID)
+		return nil
+	}))
+	require.NoError(t, client.StreamRelayNumbers(func(n RelayNumber) error {
+		numberIDs = append(numberIDs, n.ID)
+		return nil
+	}))
+	require.NoError(t, client.StreamInboundContacts(func(c InboundContact) error {
+		contactIDs = append(contactIDs, c.ID)
+		return nil
+	}))
+
+	assert.Equal(t, []int{3}, domainIDs)
+	assert.Equal(t, []int{4}, numberIDs)
+	assert.Equal(t, []int{5, 6}, contactIDs)
+}
+
+func TestDecodeArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{name: "empty array", input: `[]`, want: nil},
+		{name: "numbers", input: `[1, 2, 3]`, want: []int{1, 2, 3}},
+		{name: "not an array", input: `{"id": 1}`, wantErr: true},
+		{name: "truncated", input: `[1, 2`, want: []int{1, 2}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			err := decodeArray(strings.NewReader(tt.input), func(n int) error {
+				got = append(got, n)
+				return nil
+			})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 )
 
 // Firefox Relay Profile
@@ -197,6 +200,14 @@ type VerifyRealPhoneRequest struct {
 	VerificationCode string `json:"verification_code"`
 }
 
+// Errors matched by APIError, for use with errors.Is.
+var (
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrNotFound        = errors.New("not found")
+	ErrPremiumRequired = errors.New("premium required")
+	ErrRateLimited     = errors.New("rate limited")
+)
+
 type APIError struct {
 	StatusCode int
 	Body       string
@@ -205,3 +216,37 @@ type APIError struct {
 func (e *APIError) Error() string {
 	return e.Body
 }
+
+// Message returns the detail of a JSON error response such as
+// {"detail": "Not found."}, or the whole body otherwise.
+func (e *APIError) Message() string {
+	var resp struct {
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &resp); err == nil && resp.Detail != "" {
+		return resp.Detail
+	}
+	return strings.TrimSpace(e.Body)
+}
+
+// Is classifies the error by status code. The API rejects premium-only
+// requests from free accounts with 402, or with 403 and a message that
+// mentions premium.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || (e.StatusCode == http.StatusForbidden && !e.isPremiumRequired())
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrPremiumRequired:
+		return e.isPremiumRequired()
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+func (e *APIError) isPremiumRequired() bool {
+	return e.StatusCode == http.StatusPaymentRequired ||
+		(e.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(e.Body), "premium"))
+}
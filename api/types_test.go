@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -260,3 +262,40 @@ func TestProfile_BounceStatusMarshal(t *testing.T) {
 
 	t.Logf("Successfully marshaled profile with bounce_status as array [bool, string]")
 }
+
+func TestAPIError_Is(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *APIError
+		target error
+	}{
+		{"unauthorized", &APIError{StatusCode: 401, Body: `{"detail": "Invalid token."}`}, ErrUnauthorized},
+		{"forbidden", &APIError{StatusCode: 403, Body: `{"detail": "You do not have permission."}`}, ErrUnauthorized},
+		{"not found", &APIError{StatusCode: 404, Body: `{"detail": "Not found."}`}, ErrNotFound},
+		{"payment required", &APIError{StatusCode: 402}, ErrPremiumRequired},
+		{"forbidden for free accounts", &APIError{StatusCode: 403, Body: `{"detail": "Must be premium to set custom subdomain."}`}, ErrPremiumRequired},
+		{"rate limited", &APIError{StatusCode: 429}, ErrRateLimited},
+		{"server error", &APIError{StatusCode: 500}, nil},
+	}
+
+	targets := []error{ErrUnauthorized, ErrNotFound, ErrPremiumRequired, ErrRateLimited}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("request: %w", tt.err)
+			for _, target := range targets {
+				if got, want := errors.Is(wrapped, target), target == tt.target; got != want {
+					t.Errorf("errors.Is(%v) = %v, want %v", target, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIError_Message(t *testing.T) {
+	if got := (&APIError{Body: `{"detail": "Not found."}`}).Message(); got != "Not found." {
+		t.Errorf("Message() = %q, want %q", got, "Not found.")
+	}
+	if got := (&APIError{Body: "Bad Gateway\n"}).Message(); got != "Bad Gateway" {
+		t.Errorf("Message() = %q, want %q", got, "Bad Gateway")
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api <METHOD> <path>",
+	Short: "Make an authenticated Firefox Relay API request",
+	Long: `Make an authenticated request to the Firefox Relay API and print the response.
+
+The path is relative to ` + api.APIBasePath + ` unless it starts with "/".
+Fields passed with --field are sent as a JSON object body, or as query
+parameters for GET requests. Values of --field are typed: true, false, null
+and numbers are sent as JSON literals; use --raw-field to always send a string.
+
+Examples:
+  ffrelayctl api GET relayaddresses/
+  ffrelayctl api GET profiles/ --include
+  ffrelayctl api PATCH relayaddresses/12345/ -f enabled=false -f description=Shopping
+  ffrelayctl api POST relayaddresses/ --input mask.json
+  ffrelayctl api GET /api/v1/relaynumber/search/?area_code=430`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+		requester, ok := cfg.Client.(api.Requester)
+		if !ok {
+			return fmt.Errorf("the configured client does not support raw API requests")
+		}
+
+		method := strings.ToUpper(args[0])
+		path := args[1]
+		if !strings.HasPrefix(path, "/") {
+			path = api.APIBasePath + path
+		}
+
+		fields, err := cmd.Flags().GetStringArray("field")
+		if err != nil {
+			return fmt.Errorf("failed to get field flag: %w", err)
+		}
+		rawFields, err := cmd.Flags().GetStringArray("raw-field")
+		if err != nil {
+			return fmt.Errorf("failed to get raw-field flag: %w", err)
+		}
+		input, err := cmd.Flags().GetString("input")
+		if err != nil {
+			return fmt.Errorf("failed to get input flag: %w", err)
+		}
+		include, err := cmd.Flags().GetBool("include")
+		if err != nil {
+			return fmt.Errorf("failed to get include flag: %w", err)
+		}
+
+		params, err := parseAPIFields(fields, rawFields)
+		if err != nil {
+			return err
+		}
+
+		var body io.Reader
+		switch {
+		case input != "":
+			data, err := readAPIInput(cmd, input)
+			if err != nil {
+				return err
+			}
+			body = bytes.NewReader(data)
+		case len(params) > 0 && method == http.MethodGet:
+			path, err = addQueryParams(path, params)
+			if err != nil {
+				return err
+			}
+		case len(params) > 0:
+			data, err := json.Marshal(params)
+			if err != nil {
+				return fmt.Errorf("failed to marshal request: %w", err)
+			}
+			body = bytes.NewReader(data)
+		}
+
+		req, err := requester.NewRequest(method, path, body)
+		if err != nil {
+			return err
+		}
+		resp, err := requester.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if include {
+			printResponseHeaders(out, resp)
+		}
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &api.APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		}
+
+		if len(bytes.TrimSpace(respBody)) == 0 {
+			return nil
+		}
+
+		v, err := decodeAPIResponse(respBody)
+		if err != nil {
+			_, err = out.Write(respBody)
+			return err
+		}
+		return cfg.Printer.Fprint(out, v)
+	},
+}
+
+// decodeAPIResponse decodes a JSON response body, keeping numbers as
+// json.Number so that large IDs are printed without losing precision.
+func decodeAPIResponse(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+func parseAPIFields(fields, rawFields []string) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for _, f := range fields {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q: expected key=value", f)
+		}
+		params[key] = typedFieldValue(value)
+	}
+	for _, f := range rawFields {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid raw field %q: expected key=value", f)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+func typedFieldValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+	// ParseFloat also accepts NaN and infinities, which JSON cannot
+	// represent, so those are sent as strings.
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		return n
+	}
+	return value
+}
+
+func addQueryParams(path string, params map[string]interface{}) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	query := u.Query()
+	for key, value := range params {
+		if value == nil {
+			query.Set(key, "")
+			continue
+		}
+		query.Set(key, fmt.Sprint(value))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func readAPIInput(cmd *cobra.Command, input string) ([]byte, error) {
+	if input == "-" {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body from stdin: %w", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return data, nil
+}
+
+func printResponseHeaders(w io.Writer, resp *http.Response) {
+	fmt.Fprintf(w, "%s %s\n", resp.Proto, resp.Status)
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(w, "%s: %s\n", name, value)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+
+	apiCmd.Flags().StringArrayP("field", "f", nil, "Add a typed key=value field to the request (repeatable)")
+	apiCmd.Flags().StringArray("raw-field", nil, "Add a string key=value field to the request (repeatable)")
+	apiCmd.Flags().String("input", "", "File to use as the request body (use \"-\" for stdin)")
+	apiCmd.Flags().BoolP("include", "i", false, "Print the HTTP status line and response headers")
+	apiCmd.MarkFlagsMutuallyExclusive("input", "field")
+	apiCmd.MarkFlagsMutuallyExclusive("input", "raw-field")
+}
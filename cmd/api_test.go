@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []string
+		rawFields []string
+		want      map[string]interface{}
+	}{
+		{"typed values", []string{"enabled=false", "id=12", "ratio=0.5", "note=null", "name=Shopping"}, nil,
+			map[string]interface{}{"enabled": false, "id": int64(12), "ratio": 0.5, "note": nil, "name": "Shopping"}},
+		{"raw values", nil, []string{"enabled=false", "id=12", "note=null"},
+			map[string]interface{}{"enabled": "false", "id": "12", "note": "null"}},
+		{"raw field wins", []string{"id=12"}, []string{"id=12"}, map[string]interface{}{"id": "12"}},
+		{"value with equals sign", []string{"q=a=b"}, nil, map[string]interface{}{"q": "a=b"}},
+		{"empty value", []string{"description="}, nil, map[string]interface{}{"description": ""}},
+		{"NaN and infinities are strings", []string{"a=NaN", "b=Inf", "c=-infinity"}, nil,
+			map[string]interface{}{"a": "NaN", "b": "Inf", "c": "-infinity"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := parseAPIFields(tt.fields, tt.rawFields)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+			_, err = json.Marshal(params)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseAPIFields_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    []string
+		rawFields []string
+		wantErr   string
+	}{
+		{"missing equals sign", []string{"enabled"}, nil, `invalid field "enabled": expected key=value`},
+		{"missing key", []string{"=false"}, nil, `invalid field "=false": expected key=value`},
+		{"raw field", nil, []string{"note"}, `invalid raw field "note": expected key=value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAPIFields(tt.fields, tt.rawFields)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAddQueryParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"no query", "/api/v1/relayaddresses/", map[string]interface{}{"enabled": true}, "/api/v1/relayaddresses/?enabled=true"},
+		{"merged into query", "/api/v1/relaynumber/search/?area_code=430", map[string]interface{}{"limit": int64(5)}, "/api/v1/relaynumber/search/?area_code=430&limit=5"},
+		{"overrides query", "/api/v1/relaynumber/search/?area_code=430", map[string]interface{}{"area_code": "512"}, "/api/v1/relaynumber/search/?area_code=512"},
+		{"null and escaping", "/api/v1/relayaddresses/", map[string]interface{}{"description": nil, "q": "a&b c"}, "/api/v1/relayaddresses/?description=&q=a%26b+c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addQueryParams(tt.path, tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := addQueryParams("/api/v1/%zz", map[string]interface{}{"a": "b"})
+	assert.ErrorContains(t, err, `invalid path "/api/v1/%zz"`)
+}
+
+func TestReadAPIInput(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader(`{"enabled": false}`))
+	data, err := readAPIInput(cmd, "-")
+	require.NoError(t, err)
+	assert.Equal(t, `{"enabled": false}`, string(data))
+
+	path := filepath.Join(t.TempDir(), "body.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"description": "x"}`), 0o600))
+	data, err = readAPIInput(cmd, path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"description": "x"}`, string(data))
+
+	_, err = readAPIInput(cmd, filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read request body")
+}
+
+func TestDecodeAPIResponse(t *testing.T) {
+	v, err := decodeAPIResponse([]byte(`{"id": 9007199254740993, "ratio": 0.5}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": json.Number("9007199254740993"), "ratio": json.Number("0.5")}, v)
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": 9007199254740993, "ratio": 0.5}`, string(data))
+
+	_, err = decodeAPIResponse([]byte(`{"id": 1} trailing`))
+	assert.Error(t, err)
+	_, err = decodeAPIResponse([]byte(`<html>`))
+	assert.Error(t, err)
+}
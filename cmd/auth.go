@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hastefuI/ffrelayctl/credentials"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage stored API credentials",
+	Long: `Store your Firefox Relay API key in a passphrase-encrypted credentials file.
+
+Stored credentials are used when no key is given with --key, FFRELAYCTL_KEY
+or the selected config context. Each config context has its own entry.
+Set FFRELAYCTL_PASSPHRASE to unlock them non-interactively.`,
+	Annotations: map[string]string{modeAnnotation: modeNoKey},
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store an API key in the encrypted credentials file",
+	Long: `Prompt for a Firefox Relay API key, validate it against the API, and store it
+encrypted with a passphrase.
+
+The key can also be piped on stdin for non-interactive use.
+
+Examples:
+  ffrelayctl auth login
+  ffrelayctl auth login --context work`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+
+		key, err := readSecret(cmd, "Relay API key: ")
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("API key must not be empty")
+		}
+
+		cfg.APIKey = key
+		status, err := fetchAuthStatus(cfg, "credential store")
+		if err != nil {
+			return fmt.Errorf("failed to validate API key: %w", err)
+		}
+
+		passphrase, err := newPassphrase(cmd)
+		if err != nil {
+			return err
+		}
+
+		store, err := credentialStore()
+		if err != nil {
+			return err
+		}
+		if err := store.Save(status.Context, key, passphrase); err != nil {
+			return err
+		}
+		if err := clearCompletionCache(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Logged in as %s. Credentials for context %q stored in %s.\n",
+			cfg.Printer.Redact(status.Email), status.Context, store.Path())
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the account for the active API key",
+	Long: `Show which account the active API key belongs to, whether it has Premium,
+where the key was found, and the key fingerprint.
+
+Examples:
+  ffrelayctl auth status`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+
+		key, source, err := resolveAPIKey(cmd, cfg)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("not logged in.\nRun 'ffrelayctl auth login' or provide a key with --key")
+		}
+
+		cfg.APIKey = key
+		status, err := fetchAuthStatus(cfg, source)
+		if err != nil {
+			return err
+		}
+		return cfg.Printer.Print(status)
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove stored credentials",
+	Long: `Remove the stored API key for the selected config context.
+
+Examples:
+  ffrelayctl auth logout
+  ffrelayctl auth logout --context work`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+
+		store, err := credentialStore()
+		if err != nil {
+			return err
+		}
+		name := credentialName(cfg)
+		removed, err := store.Delete(name)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			fmt.Fprintf(cmd.OutOrStdout(), "No stored credentials for context %q.\n", name)
+			return nil
+		}
+		if err := clearCompletionCache(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed stored credentials for context %q.\n", name)
+		return nil
+	},
+}
+
+func fetchAuthStatus(cfg *CmdConfig, source string) (output.AuthStatus, error) {
+	status := output.AuthStatus{
+		Context:     credentialName(cfg),
+		KeySource:   source,
+		Fingerprint: credentials.Fingerprint(cfg.APIKey),
+	}
+
+	client, err := cfg.clientFactory(cfg)
+	if err != nil {
+		return status, err
+	}
+
+	profiles, err := client.GetProfiles()
+	if err != nil {
+		return status, err
+	}
+	if len(profiles) > 0 {
+		status.HasPremium = profiles[0].HasPremium
+	}
+
+	users, err := client.ListUsers()
+	if err != nil {
+		return status, err
+	}
+	if len(users) > 0 {
+		status.Email = users[0].Email
+	}
+
+	return status, nil
+}
+
+// newPassphrase reads the passphrase for new credentials, asking for it twice
+// when prompting interactively.
+func newPassphrase(cmd *cobra.Command) (string, error) {
+	if passphrase := os.Getenv(envPassphraseName); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := readPassphrase(cmd, "Passphrase to encrypt the API key: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", credentials.ErrEmptyPassphrase
+	}
+
+	confirm, err := readSecret(cmd, "Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm != passphrase {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	printsResults(authStatusCmd, output.AuthStatus{})
+}
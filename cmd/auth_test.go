@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/credentials"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_LoginStatusLogout(t *testing.T) {
+	c := newCLI(t, newFakeRelay())
+	t.Setenv(envPassphraseName, "correct horse")
+
+	stdout, stderr, status := c.runWithInput("relay-key\n", "auth", "login")
+	require.Equal(t, 0, status, stderr)
+	assert.Contains(t, stdout, `Logged in as me@example.com. Credentials for context "default" stored in`)
+
+	stdout, stderr, status = c.run("auth", "status", "-o", "json")
+	require.Equal(t, 0, status, stderr)
+	assert.JSONEq(t, `{
+		"context": "default",
+		"email": "me@example.com",
+		"has_premium": true,
+		"key_source": "credential store",
+		"fingerprint": "`+credentials.Fingerprint("relay-key")+`"
+	}`, stdout)
+
+	t.Setenv(envPassphraseName, "wrong")
+	_, stderr, status = c.run("auth", "status")
+	assert.Equal(t, exitGeneral.status, status)
+	assert.Contains(t, stderr, "failed to unlock stored credentials")
+
+	stdout, _, status = c.run("auth", "logout")
+	require.Equal(t, 0, status)
+	assert.Equal(t, "Removed stored credentials for context \"default\".\n", stdout)
+
+	stdout, _, status = c.run("auth", "logout")
+	require.Equal(t, 0, status)
+	assert.Equal(t, "No stored credentials for context \"default\".\n", stdout)
+
+	_, stderr, status = c.run("auth", "status")
+	assert.Equal(t, exitGeneral.status, status)
+	assert.Contains(t, stderr, "not logged in")
+}
+
+func TestAuth_LoginErrors(t *testing.T) {
+	t.Run("empty key", func(t *testing.T) {
+		c := newCLI(t, newFakeRelay())
+		t.Setenv(envPassphraseName, "correct horse")
+		_, stderr, status := c.runWithInput("\n", "auth", "login")
+		assert.Equal(t, exitGeneral.status, status)
+		assert.Contains(t, stderr, "API key must not be empty")
+	})
+
+	t.Run("rejected key", func(t *testing.T) {
+		client := newFakeRelay()
+		client.fail["profiles"] = true
+		c := newCLI(t, client)
+		t.Setenv(envPassphraseName, "correct horse")
+		_, stderr, status := c.runWithInput("relay-key\n", "auth", "login")
+		assert.Equal(t, exitGeneral.status, status)
+		assert.Contains(t, stderr, "failed to validate API key")
+
+		_, stderr, _ = c.run("auth", "status")
+		assert.Contains(t, stderr, "not logged in", "nothing is stored")
+	})
+
+	t.Run("no passphrase without a terminal", func(t *testing.T) {
+		c := newCLI(t, newFakeRelay())
+		_, stderr, status := c.runWithInput("relay-key\n", "auth", "login")
+		assert.Equal(t, exitGeneral.status, status)
+		assert.Contains(t, stderr, "stored credentials are encrypted")
+	})
+}
+
+func TestReadSecret_SharesInput(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.WithValue(context.Background(), configKey{}, &CmdConfig{}))
+	cmd.SetIn(strings.NewReader("first\nsecond\n"))
+
+	first, err := readSecret(cmd, "First: ")
+	require.NoError(t, err)
+	second, err := readSecret(cmd, "Second: ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, []string{first, second})
+
+	_, err = readSecret(cmd, "Third: ")
+	assert.ErrorContains(t, err, "failed to read input")
+}
@@ -0,0 +1,561 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/journal"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	opCreateMask     = "create-mask"
+	opUpdateMask     = "update-mask"
+	opDeleteMask     = "delete-mask"
+	opBlockContact   = "block-contact"
+	opUnblockContact = "unblock-contact"
+	opEnablePhone    = "enable-phone"
+	opDisablePhone   = "disable-phone"
+
+	maxBatchLine = 1 << 20
+)
+
+// batchFields lists the fields each operation accepts besides "op".
+var batchFields = map[string][]string{
+	opCreateMask:     {"type", "address", "description", "generated_for", "used_on", "enabled", "block_list_emails"},
+	opUpdateMask:     {"mask", "type", "description", "used_on", "enabled", "block_list_emails"},
+	opDeleteMask:     {"mask", "type"},
+	opBlockContact:   {"id"},
+	opUnblockContact: {"id"},
+	opEnablePhone:    {"id"},
+	opDisablePhone:   {"id"},
+}
+
+// batchOp is one line of batch input.
+type batchOp struct {
+	Op              string    `json:"op"`
+	Mask            *maskName `json:"mask"`
+	Type            string    `json:"type"`
+	Address         string    `json:"address"`
+	Description     *string   `json:"description"`
+	GeneratedFor    string    `json:"generated_for"`
+	UsedOn          *string   `json:"used_on"`
+	Enabled         *bool     `json:"enabled"`
+	BlockListEmails *bool     `json:"block_list_emails"`
+	ID              *int      `json:"id"`
+
+	line int
+	raw  []byte
+	item int
+	ref  maskRef
+}
+
+// batchPlan is what a batch runs, as stored in its journal. Masks are
+// stored resolved, so a resumed batch acts on the same masks.
+type batchPlan struct {
+	Operations []batchStep `json:"operations"`
+}
+
+type batchStep struct {
+	Line int             `json:"line"`
+	Op   json.RawMessage `json:"op"`
+	Mask string          `json:"mask,omitempty"`
+}
+
+// maskName is a mask reference given as a JSON string or number.
+type maskName string
+
+func (m *maskName) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*m = maskName(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("mask must be a string or a number")
+	}
+	*m = maskName(s)
+	return nil
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch [<FILE>]",
+	Short: "Run operations read as JSON Lines",
+	Long: `Run operations read from a file, or from stdin if no file or "-" is given,
+one JSON object per line.
+
+Every operation is checked before any runs, and masks given by address or
+description are resolved. If any operation is invalid, nothing is run.
+Operations then run up to --concurrency at a time, in no particular order;
+use --concurrency 1 to run them in order.
+
+Operations:
+  {"op": "create-mask", "description": "Shopping", "generated_for": "shop.com"}
+  {"op": "create-mask", "type": "custom", "address": "shop", "enabled": false}
+  {"op": "update-mask", "mask": "abc123@mozmail.com", "enabled": false}
+  {"op": "update-mask", "mask": "custom:7", "description": "New", "block_list_emails": true}
+  {"op": "delete-mask", "mask": 12345}
+  {"op": "block-contact", "id": 31}       (also unblock-contact)
+  {"op": "enable-phone", "id": 7}         (also disable-phone)
+
+create-mask also accepts used_on, and creates random masks unless "type" is
+"custom". Masks are referenced as on the command line (see 'masks get --help');
+"type" qualifies numeric IDs, which otherwise refer to random masks.
+
+One JSON line is written per operation, in input order, with the fields
+line, op, status (succeeded, failed or skipped), id, result and error. If
+only some operations fail, the exit code is 8.
+
+` + journalHelp + `
+A resumed batch runs the operations that did not succeed, on the masks they
+were resolved to, and writes results only for them.
+
+Examples:
+  ffrelayctl batch changes.jsonl
+  ffrelayctl batch --dry-run < changes.jsonl
+  generate-changes | ffrelayctl batch --concurrency 8 > results.jsonl
+  ffrelayctl batch changes.jsonl --journal changes.journal
+  ffrelayctl batch --resume changes.journal`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			return fmt.Errorf("failed to get concurrency flag: %w", err)
+		}
+		if concurrency < 1 {
+			return withExitCode(fmt.Errorf("--concurrency must be at least 1"), exitUsage)
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return fmt.Errorf("failed to get dry-run flag: %w", err)
+		}
+
+		var ops []*batchOp
+		var j *journal.Journal
+		// total also counts the operations done by earlier runs of a
+		// resumed journal.
+		var total int
+		if isResume(cmd) {
+			if len(args) > 0 {
+				return withExitCode(fmt.Errorf("a file cannot be combined with --resume"), exitUsage)
+			}
+			if ops, total, j, err = resumeBatch(cmd, cfg); err != nil {
+				return err
+			}
+		} else {
+			in := cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open batch file: %w", err)
+				}
+				defer f.Close()
+				in = f
+			}
+
+			if ops, err = readBatch(in); err != nil {
+				return err
+			}
+			if err := resolveBatch(cfg, ops); err != nil {
+				return err
+			}
+			if dryRun {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%d operations are valid.\n", len(ops))
+				return nil
+			}
+
+			var plan batchPlan
+			for i, op := range ops {
+				op.item = i
+				step := batchStep{Line: op.line, Op: op.raw}
+				if op.Mask != nil {
+					step.Mask = op.ref.String()
+				}
+				plan.Operations = append(plan.Operations, step)
+			}
+			if j, err = startJournal(cmd, cfg, "batch", plan); err != nil {
+				return err
+			}
+			total = len(ops)
+		}
+
+		stop := cfg.gracefulStop()
+		lw := cfg.Printer.JSONLineWriter(cmd.OutOrStdout())
+		failed, skipped, firstErr, writeErr, journalErr := runBatch(stop, cfg, j, ops, concurrency, lw)
+		runErr := failureError(stop, "operations", total, failed, skipped, firstErr)
+		switch {
+		case journalErr != nil:
+			runErr = journalErr
+		case writeErr != nil:
+			runErr = writeErr
+		}
+		if err := finishJournal(cmd, "batch", j, runErr == nil); err != nil && runErr == nil {
+			runErr = err
+		}
+		return runErr
+	},
+}
+
+// resumeBatch reads the operations of the journal given with --resume that
+// did not succeed, and the number of operations in the journal.
+func resumeBatch(cmd *cobra.Command, cfg *CmdConfig) ([]*batchOp, int, *journal.Journal, error) {
+	var plan batchPlan
+	j, done, err := resumeJournal(cmd, cfg, "batch", &plan)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	var ops []*batchOp
+	for i, step := range plan.Operations {
+		if done[i] {
+			continue
+		}
+		op, err := parseBatchOp(step.Op)
+		if err != nil {
+			j.Close()
+			return nil, 0, nil, fmt.Errorf("%s: line %d: %w", j.Path(), step.Line, err)
+		}
+		if step.Mask != "" {
+			mask := maskName(step.Mask)
+			op.Mask = &mask
+		}
+		op.line, op.raw, op.item = step.Line, step.Op, i
+		ops = append(ops, op)
+	}
+	if err := resolveBatch(cfg, ops); err != nil {
+		j.Close()
+		return nil, 0, nil, err
+	}
+	return ops, len(plan.Operations), j, nil
+}
+
+// readBatch parses and checks every operation, skipping blank lines. All
+// problems are reported together.
+func readBatch(r io.Reader) ([]*batchOp, error) {
+	var ops []*batchOp
+	var problems []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxBatchLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		op, err := parseBatchOp(text)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		op.line, op.raw = line, append([]byte(nil), text...)
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); err != nil {
+		if !errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("failed to read operations: %w", err)
+		}
+		// The scanner cannot go past a line that is too long.
+		problems = append(problems, fmt.Sprintf("line %d: too long, the limit is %d bytes", line+1, maxBatchLine))
+	}
+	if len(problems) > 0 {
+		return nil, withExitCode(fmt.Errorf("invalid operations, none were run:\n  %s", strings.Join(problems, "\n  ")), exitUsage)
+	}
+	return ops, nil
+}
+
+func parseBatchOp(text []byte) (*batchOp, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(text, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	var op batchOp
+	if err := json.Unmarshal(text, &op); err != nil {
+		return nil, fmt.Errorf("invalid operation: %v", err)
+	}
+
+	allowed, ok := batchFields[op.Op]
+	if !ok {
+		ops := make([]string, 0, len(batchFields))
+		for name := range batchFields {
+			ops = append(ops, name)
+		}
+		sort.Strings(ops)
+		return nil, fmt.Errorf("unknown op %q, must be one of: %s", op.Op, strings.Join(ops, ", "))
+	}
+	for name := range fields {
+		if name != "op" && !contains(allowed, name) {
+			return nil, fmt.Errorf("%s does not accept %q", op.Op, name)
+		}
+	}
+
+	if op.Type != "" && op.Type != "random" && op.Type != "custom" {
+		return nil, fmt.Errorf("type must be random or custom, not %q", op.Type)
+	}
+	switch op.Op {
+	case opCreateMask:
+		if op.Type == "custom" {
+			if op.Address == "" {
+				return nil, fmt.Errorf("address is required for custom domain masks")
+			}
+			if op.GeneratedFor != "" || op.UsedOn != nil {
+				return nil, fmt.Errorf("generated_for and used_on only apply to random masks")
+			}
+		} else if op.Address != "" {
+			return nil, fmt.Errorf("address only applies to custom domain masks")
+		}
+	case opUpdateMask, opDeleteMask:
+		if op.Mask == nil || *op.Mask == "" {
+			return nil, fmt.Errorf("%s requires mask", op.Op)
+		}
+		if op.Op == opUpdateMask && op.Description == nil && op.UsedOn == nil && op.Enabled == nil && op.BlockListEmails == nil {
+			return nil, fmt.Errorf("update-mask requires a change: description, used_on, enabled or block_list_emails")
+		}
+	default:
+		if op.ID == nil {
+			return nil, fmt.Errorf("%s requires id", op.Op)
+		}
+	}
+	return &op, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveBatch resolves the masks referenced by update-mask and
+// delete-mask, listing masks once if any is given by address or
+// description.
+func resolveBatch(cfg *CmdConfig, ops []*batchOp) error {
+	var masks []output.CombinedMask
+	listed := false
+	var problems []string
+	for _, op := range ops {
+		if op.Mask == nil {
+			continue
+		}
+		var typ *bool
+		if op.Type != "" {
+			random := op.Type == "random"
+			typ = &random
+		}
+
+		ref, ok, err := parseMaskID(string(*op.Mask), nil)
+		if err == nil && !ok {
+			if !listed {
+				if masks, err = listCombinedMasks(cfg, nil); err != nil {
+					return err
+				}
+				listed = true
+			}
+			ref, err = lookupMask(cfg, masks, string(*op.Mask))
+		}
+		if err == nil {
+			switch {
+			case ref.random == nil:
+				// As on the command line, numeric IDs are random masks
+				// unless a type is given.
+				random := typ == nil || *typ
+				ref.random = &random
+			case typ != nil && *typ != *ref.random:
+				err = fmt.Errorf("%s is a %s mask, not %s", *op.Mask, maskTypeName(*ref.random), op.Type)
+			}
+		}
+		if err == nil && op.UsedOn != nil && !*ref.random {
+			err = fmt.Errorf("used_on only applies to random masks")
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", op.line, err))
+			continue
+		}
+		op.ref = ref
+	}
+	if len(problems) > 0 {
+		return withExitCode(fmt.Errorf("invalid operations, none were run:\n  %s", strings.Join(problems, "\n  ")), exitUsage)
+	}
+	return nil
+}
+
+func maskTypeName(random bool) string {
+	if random {
+		return "random"
+	}
+	return "custom"
+}
+
+// runBatch runs ops at most concurrency at a time, recording each outcome
+// in j, and writes their results in input order, each as soon as it and all
+// before it are done. Operations not started when stop is done, the
+// journal fails or a result cannot be written are skipped.
+func runBatch(stop context.Context, cfg *CmdConfig, j *journal.Journal, ops []*batchOp, concurrency int, lw *output.LineWriter) (failed, skipped int, firstErr, writeErr, journalErr error) {
+	ctx, cancel := context.WithCancel(stop)
+	defer cancel()
+
+	results := make([]*output.BatchResult, len(ops))
+	errs := make([]error, len(ops))
+	var mu sync.Mutex
+	next := 0
+	finish := func(i int, result *output.BatchResult, err error) {
+		if result.Status != "skipped" {
+			entry := journal.Entry{Item: ops[i].item, Done: err == nil}
+			if err != nil {
+				entry.Error = errorMessage(err)
+			}
+			if err := j.Record(entry); err != nil {
+				mu.Lock()
+				if journalErr == nil {
+					journalErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results[i], errs[i] = result, err
+		for next < len(results) && results[next] != nil {
+			if writeErr == nil {
+				if writeErr = lw.Write(results[next]); writeErr != nil {
+					cancel()
+				}
+			}
+			next++
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, op := range ops {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			finish(i, &output.BatchResult{Line: op.line, Op: op.Op, Status: "skipped", ID: op.knownID()}, nil)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, op *batchOp) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := &output.BatchResult{Line: op.line, Op: op.Op, Status: "succeeded", ID: op.knownID()}
+			item, id, err := op.run(cfg)
+			if err != nil {
+				msg := errorMessage(err)
+				result.Status, result.Error = "failed", &msg
+			} else {
+				result.Result = item
+				if id != nil {
+					result.ID = id
+				}
+			}
+			finish(i, result, err)
+		}(i, op)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		switch r.Status {
+		case "failed":
+			failed++
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		case "skipped":
+			skipped++
+		}
+	}
+	return failed, skipped, firstErr, writeErr, journalErr
+}
+
+// knownID returns the ID an operation acts on, if known before it runs.
+func (op *batchOp) knownID() *int {
+	switch {
+	case op.ID != nil:
+		return op.ID
+	case op.Mask != nil:
+		id := op.ref.id
+		return &id
+	}
+	return nil
+}
+
+// run performs op and returns the item the API returned and, for created
+// items, their ID.
+func (op *batchOp) run(cfg *CmdConfig) (interface{}, *int, error) {
+	boolPtr := func(val bool) *bool { return &val }
+
+	switch op.Op {
+	case opCreateMask:
+		if op.Type == "custom" {
+			req := api.CreateDomainAddressRequest{
+				Address:         op.Address,
+				Enabled:         op.Enabled == nil || *op.Enabled,
+				Description:     stringValue(op.Description),
+				BlockListEmails: op.BlockListEmails != nil && *op.BlockListEmails,
+			}
+			mask, err := cfg.Client.CreateDomainAddress(req)
+			if err != nil {
+				return nil, nil, err
+			}
+			return mask, &mask.ID, nil
+		}
+		req := api.CreateRelayAddressRequest{
+			Enabled:         op.Enabled == nil || *op.Enabled,
+			Description:     stringValue(op.Description),
+			GeneratedFor:    op.GeneratedFor,
+			UsedOn:          stringValue(op.UsedOn),
+			BlockListEmails: op.BlockListEmails != nil && *op.BlockListEmails,
+		}
+		mask, err := cfg.Client.CreateRelayAddress(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return mask, &mask.ID, nil
+	case opUpdateMask:
+		fields := commonUpdateFields{enabled: op.Enabled, description: op.Description, blockListEmails: op.BlockListEmails}
+		mask, err := updateMask(cfg, op.ref.id, *op.ref.random, fields, op.UsedOn)
+		return mask, nil, err
+	case opDeleteMask:
+		return nil, nil, deleteMask(cfg, op.ref.id, *op.ref.random)
+	case opBlockContact, opUnblockContact:
+		contact, err := cfg.Client.UpdateInboundContact(*op.ID, api.UpdateInboundContactRequest{Blocked: boolPtr(op.Op == opBlockContact)})
+		return contact, nil, err
+	case opEnablePhone, opDisablePhone:
+		number, err := cfg.Client.UpdateRelayNumber(*op.ID, api.UpdateRelayNumberRequest{Enabled: boolPtr(op.Op == opEnablePhone)})
+		return number, nil, err
+	}
+	return nil, nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+	batchCmd.Flags().Int("concurrency", defaultBulkConcurrency, "Number of operations to run at a time")
+	batchCmd.Flags().Bool("dry-run", false, "Check the operations without running them")
+	addJournalFlags(batchCmd)
+}
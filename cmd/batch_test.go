@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/journal"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchOp(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr string
+	}{
+		{`{"op": "create-mask", "description": "Shop", "generated_for": "shop.com", "used_on": "shop.com"}`, ""},
+		{`{"op": "create-mask", "type": "custom", "address": "shop", "enabled": false}`, ""},
+		{`{"op": "update-mask", "mask": 101, "block_list_emails": true}`, ""},
+		{`{"op": "update-mask", "mask": "custom:7", "type": "custom", "description": ""}`, ""},
+		{`{"op": "delete-mask", "mask": "abc123@mozmail.com"}`, ""},
+		{`{"op": "block-contact", "id": 31}`, ""},
+		{`{"op": "enable-phone", "id": 7}`, ""},
+
+		{`{"op": "create-mask"`, "invalid JSON"},
+		{`["create-mask"]`, "invalid JSON"},
+		{`{"op": "rename-mask"}`, `unknown op "rename-mask", must be one of: block-contact, create-mask, delete-mask, disable-phone, enable-phone, unblock-contact, update-mask`},
+		{`{"mask": 101}`, `unknown op ""`},
+		{`{"op": "create-mask", "id": 1}`, `create-mask does not accept "id"`},
+		{`{"op": "create-mask", "type": "alias"}`, `type must be random or custom, not "alias"`},
+		{`{"op": "create-mask", "type": "custom"}`, "address is required for custom domain masks"},
+		{`{"op": "create-mask", "type": "custom", "address": "a", "used_on": "x.com"}`, "generated_for and used_on only apply to random masks"},
+		{`{"op": "create-mask", "address": "a"}`, "address only applies to custom domain masks"},
+		{`{"op": "update-mask", "enabled": true}`, "update-mask requires mask"},
+		{`{"op": "update-mask", "mask": 101}`, "update-mask requires a change"},
+		{`{"op": "update-mask", "mask": {"id": 101}, "enabled": true}`, "mask must be a string or a number"},
+		{`{"op": "update-mask", "mask": 101, "enabled": "yes"}`, "invalid operation"},
+		{`{"op": "delete-mask", "mask": ""}`, "delete-mask requires mask"},
+		{`{"op": "delete-mask", "mask": 101, "description": "x"}`, `delete-mask does not accept "description"`},
+		{`{"op": "block-contact"}`, "block-contact requires id"},
+		{`{"op": "unblock-contact", "mask": 101}`, `unblock-contact does not accept "mask"`},
+		{`{"op": "disable-phone"}`, "disable-phone requires id"},
+		{`{"op": "enable-phone", "id": "7"}`, "invalid operation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := parseBatchOp([]byte(tt.line))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReadBatch(t *testing.T) {
+	ops, err := readBatch(strings.NewReader("\n" + `{"op": "delete-mask", "mask": 101}` + "\n  \n" + `{"op": "block-contact", "id": 31}`))
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	assert.Equal(t, 2, ops[0].line)
+	assert.Equal(t, 4, ops[1].line)
+	assert.JSONEq(t, `{"op": "block-contact", "id": 31}`, string(ops[1].raw))
+}
+
+func TestReadBatch_Problems(t *testing.T) {
+	input := strings.Join([]string{
+		`{"op": "delete-mask", "mask": 101}`,
+		`{"op": "nope"}`,
+		`{"op": "block-contact"}`,
+		`{"op": "delete-mask", "mask": "` + strings.Repeat("x", maxBatchLine) + `"}`,
+		`{"op": "enable-phone"}`,
+	}, "\n")
+	_, err := readBatch(strings.NewReader(input))
+	require.Error(t, err)
+	assert.Equal(t, exitUsage, classifyError(err))
+	assert.Equal(t, `invalid operations, none were run:
+  line 2: unknown op "nope", must be one of: block-contact, create-mask, delete-mask, disable-phone, enable-phone, unblock-contact, update-mask
+  line 3: block-contact requires id
+  line 4: too long, the limit is 1048576 bytes`, err.Error())
+}
+
+func batchOps(t *testing.T, lines ...string) []*batchOp {
+	t.Helper()
+	ops, err := readBatch(strings.NewReader(strings.Join(lines, "\n")))
+	require.NoError(t, err)
+	for i, op := range ops {
+		op.item = i
+	}
+	return ops
+}
+
+func TestResolveBatch(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    string
+		wantErr string
+	}{
+		{`{"op": "delete-mask", "mask": 101}`, "random:101", ""},
+		{`{"op": "delete-mask", "mask": 101, "type": "custom"}`, "custom:101", ""},
+		{`{"op": "delete-mask", "mask": "custom:101"}`, "custom:101", ""},
+		{`{"op": "delete-mask", "mask": "news77@mozmail.com"}`, "random:102", ""},
+		{`{"op": "delete-mask", "mask": "shop"}`, "custom:101", ""},
+		{`{"op": "update-mask", "mask": "newsletter", "used_on": "x.com"}`, "random:102", ""},
+
+		{`{"op": "delete-mask", "mask": "custom:101", "type": "random"}`, "", "line 1: custom:101 is a custom mask, not random"},
+		{`{"op": "delete-mask", "mask": "shop", "type": "random"}`, "", "line 1: shop is a custom mask, not random"},
+		{`{"op": "update-mask", "mask": "shop", "used_on": "x.com"}`, "", "line 1: used_on only applies to random masks"},
+		{`{"op": "delete-mask", "mask": "nothing@mozmail.com"}`, "", `line 1: no mask matches "nothing@mozmail.com"`},
+		{`{"op": "delete-mask", "mask": "shopping"}`, "", `line 1: "shopping" matches 2 masks`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			ops := batchOps(t, tt.line)
+			err := resolveBatch(testConfig(t, newFakeRelay()), ops)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Equal(t, exitUsage, classifyError(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ops[0].ref.String())
+		})
+	}
+}
+
+// batchStatuses decodes the line and status of each result written by
+// runBatch.
+func batchStatuses(t *testing.T, out string) []string {
+	t.Helper()
+	var statuses []string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		var result output.BatchResult
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		statuses = append(statuses, result.Op+" "+result.Status)
+	}
+	return statuses
+}
+
+func TestRunBatch_PartialFailure(t *testing.T) {
+	client := newFakeRelay()
+	client.fail["update random:102"] = true
+	cfg := testConfig(t, client)
+	ops := batchOps(t,
+		`{"op": "create-mask", "description": "New"}`,
+		`{"op": "update-mask", "mask": 102, "enabled": false}`,
+		`{"op": "delete-mask", "mask": "custom:101"}`,
+		`{"op": "block-contact", "id": 31}`,
+		`{"op": "disable-phone", "id": 7}`,
+	)
+	require.NoError(t, resolveBatch(cfg, ops))
+	j, path := testJournal(t, "batch", nil)
+
+	var buf bytes.Buffer
+	failed, skipped, firstErr, writeErr, journalErr := runBatch(context.Background(), cfg, j, ops, 3, output.NewLineWriter(&buf))
+	require.NoError(t, writeErr)
+	require.NoError(t, journalErr)
+	require.NoError(t, j.Close())
+	assert.Equal(t, []string{
+		"create-mask succeeded",
+		"update-mask failed",
+		"delete-mask succeeded",
+		"block-contact succeeded",
+		"disable-phone succeeded",
+	}, batchStatuses(t, buf.String()), "results are written in input order")
+	assert.Contains(t, buf.String(), `"line":1,"op":"create-mask","status":"succeeded","id":201`)
+	assert.Contains(t, buf.String(), `"error":"Rejected."`, "API errors show their detail")
+
+	err := failureError(context.Background(), "operations", len(ops), failed, skipped, firstErr)
+	assert.EqualError(t, err, "1 of 5 operations failed")
+	assert.Equal(t, exitPartialFailure, classifyError(err))
+
+	_, done, err := journal.Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true, 2: true, 3: true, 4: true}, done)
+}
+
+func TestRunBatch_Stopped(t *testing.T) {
+	cfg := testConfig(t, newFakeRelay())
+	ops := batchOps(t,
+		`{"op": "delete-mask", "mask": 101}`,
+		`{"op": "delete-mask", "mask": 102}`,
+		`{"op": "block-contact", "id": 31}`,
+	)
+	require.NoError(t, resolveBatch(cfg, ops))
+	j, _ := testJournal(t, "batch", nil)
+	defer j.Close()
+
+	stop, cancel := context.WithCancel(context.Background())
+	cancel()
+	var buf bytes.Buffer
+	failed, skipped, firstErr, _, _ := runBatch(stop, cfg, j, ops, 2, output.NewLineWriter(&buf))
+	assert.Equal(t, []string{"delete-mask skipped", "delete-mask skipped", "block-contact skipped"}, batchStatuses(t, buf.String()))
+	assert.Contains(t, buf.String(), `"line":2,"op":"delete-mask","status":"skipped","id":102`)
+
+	err := failureError(stop, "operations", len(ops), failed, skipped, firstErr)
+	assert.EqualError(t, err, "interrupted with 3 of 3 operations not done: context canceled")
+}
+
+// failingWriter fails every write, like stdout closed by the reader.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestBatchCmd_WriteFailure(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfg := testConfig(t, newFakeRelay())
+	cmd := &cobra.Command{Use: "batch", RunE: batchCmd.RunE}
+	cmd.Flags().Int("concurrency", 1, "")
+	cmd.Flags().Bool("dry-run", false, "")
+	addJournalFlags(cmd)
+	cmd.SetContext(context.WithValue(context.Background(), configKey{}, cfg))
+	cmd.SetIn(strings.NewReader(`{"op": "block-contact", "id": 31}` + "\n" + `{"op": "disable-phone", "id": 7}`))
+	cmd.SetOut(failingWriter{})
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	err := cmd.RunE(cmd, nil)
+	assert.EqualError(t, err, "error formatting output: broken pipe")
+	assert.Equal(t, exitGeneral, classifyError(err))
+	assert.Contains(t, stderr.String(), "Progress saved", "the journal is kept")
+}
@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/journal"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+const bulkHelp = `Bulk mode changes every mask selected by --filter or --ids instead of a
+single MASK argument:
+  --filter 'generated_for~example.com'   Masks matching a filter expression
+  --ids 101,custom:7,abc123@mozmail.com  Masks given as IDs or addresses
+  --ids -                                Masks read from stdin, one per line
+The selected masks are shown and confirmed once (skip with --force), and
+--dry-run only prints them. Up to --concurrency masks are changed at a time
+and a result is printed for each; if only some succeed, the exit code is 8.
+
+` + journalHelp
+
+const defaultBulkConcurrency = 4
+
+func addBulkFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().String("filter", "", verb+" all masks matching an expression (see 'masks list --help')")
+	cmd.Flags().StringSlice("ids", nil, verb+" the masks with these IDs or addresses; - reads them from stdin")
+	cmd.Flags().Bool("dry-run", false, "Print the selected masks without changing them")
+	cmd.Flags().Int("concurrency", defaultBulkConcurrency, "Number of masks to change at a time")
+	addJournalFlags(cmd)
+	cmd.MarkFlagsMutuallyExclusive("filter", "ids", "resume")
+}
+
+func isBulk(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("filter") || cmd.Flags().Changed("ids") || isResume(cmd)
+}
+
+// bulkResults returns samples of what the bulk mode of cmd prints: the
+// selected masks with --dry-run, or the result for each mask.
+func bulkResults(cmd *cobra.Command) []interface{} {
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return []interface{}{[]output.CombinedMask{}}
+	}
+	return []interface{}{[]output.BulkResult{}}
+}
+
+// maskOrSelectorArgs accepts either one MASK argument or the bulk
+// selectors.
+func maskOrSelectorArgs(cmd *cobra.Command, args []string) error {
+	switch {
+	case isBulk(cmd) && len(args) > 0:
+		return fmt.Errorf("a mask argument cannot be combined with --filter, --ids or --resume")
+	case isBulk(cmd):
+		return nil
+	case cmd.Flags().Changed("dry-run") || cmd.Flags().Changed("concurrency") || cmd.Flags().Changed("journal"):
+		return fmt.Errorf("--dry-run, --concurrency and --journal require --filter or --ids")
+	}
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
+// bulkTargets returns the masks selected by --filter or --ids, and whether
+// they were read from stdin. Masks are listed once, from the type selected
+// by --random or from both types.
+func bulkTargets(cmd *cobra.Command, cfg *CmdConfig) ([]output.CombinedMask, bool, error) {
+	explicit, err := explicitRandom(cmd)
+	if err != nil {
+		return nil, false, err
+	}
+	var samples []interface{}
+	if explicit == nil || *explicit {
+		samples = append(samples, output.CombinedMask{Type: "random", Mask: api.RelayAddress{}})
+	}
+	if explicit == nil || !*explicit {
+		samples = append(samples, output.CombinedMask{Type: "custom", Mask: api.DomainAddress{}})
+	}
+	expr, err := listFilter(cmd, samples...)
+	if err != nil {
+		return nil, false, err
+	}
+	args, err := cmd.Flags().GetStringSlice("ids")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get ids flag: %w", err)
+	}
+	fromStdin := len(args) == 1 && args[0] == "-"
+	if fromStdin {
+		if args, err = readMaskArgs(cmd.InOrStdin()); err != nil {
+			return nil, false, err
+		}
+	}
+	if expr == nil && len(args) == 0 {
+		return nil, false, withExitCode(fmt.Errorf("no masks given with --ids"), exitUsage)
+	}
+
+	masks, err := listCombinedMasks(cfg, explicit)
+	if err != nil {
+		return nil, false, err
+	}
+	if expr != nil {
+		targets, err := filterItems(expr, masks)
+		return targets, false, err
+	}
+
+	byRef := make(map[string]output.CombinedMask, len(masks))
+	for _, m := range masks {
+		byRef[refOf(m).String()] = m
+	}
+	var targets []output.CombinedMask
+	var missing []string
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		ref, ok, err := parseMaskID(arg, explicit)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			// As for a single mask, numeric IDs are random masks unless
+			// --random=false is given.
+			random := ref.isRandom(true)
+			ref.random = &random
+		} else if ref, err = lookupMask(cfg, masks, arg); err != nil {
+			return nil, false, err
+		}
+
+		m, ok := byRef[ref.String()]
+		if !ok {
+			missing = append(missing, arg)
+			continue
+		}
+		if !seen[ref.String()] {
+			seen[ref.String()] = true
+			targets = append(targets, m)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, false, withExitCode(fmt.Errorf("no mask found for %s", strings.Join(missing, ", ")), exitNotFound)
+	}
+	return targets, fromStdin, nil
+}
+
+// readMaskArgs reads one mask per line, skipping blank lines.
+func readMaskArgs(r io.Reader) ([]string, error) {
+	var args []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			args = append(args, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read masks from stdin: %w", err)
+	}
+	return args, nil
+}
+
+// confirmBulk shows the selected masks on stderr and asks once whether to
+// go ahead, unless --force is given.
+func confirmBulk(cmd *cobra.Command, cfg *CmdConfig, targets []output.CombinedMask, verb string, fromStdin bool) (bool, error) {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return false, fmt.Errorf("failed to get force flag: %w", err)
+	}
+	if force {
+		return true, nil
+	}
+	if fromStdin {
+		return false, withExitCode(fmt.Errorf("--force is required when reading masks from stdin"), exitUsage)
+	}
+
+	w := cmd.ErrOrStderr()
+	if err := cfg.Printer.Preview().Fprint(w, targets); err != nil {
+		return false, err
+	}
+	fmt.Fprintf(w, "%s %d masks? [y/N]: ", verb, len(targets))
+	response, err := stdinReader(cmd).ReadString('\n')
+	if err != nil && response == "" {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes", nil
+}
+
+// bulkPlan is what a bulk command changes, as stored in its journal.
+type bulkPlan struct {
+	Targets []bulkTarget `json:"targets"`
+	Update  *bulkUpdate  `json:"update,omitempty"`
+}
+
+type bulkTarget struct {
+	Type        string `json:"type"`
+	ID          int    `json:"id"`
+	FullAddress string `json:"full_address"`
+}
+
+type bulkUpdate struct {
+	Enabled         *bool   `json:"enabled,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	BlockListEmails *bool   `json:"block_list_emails,omitempty"`
+	UsedOn          *string `json:"used_on,omitempty"`
+}
+
+// runBulk selects masks, confirms and applies fn to each of them, then
+// prints a result per mask. status is the status of masks fn succeeded on.
+// With --resume, the masks and update are read from the journal instead
+// and only the masks that did not succeed are changed.
+func runBulk(cmd *cobra.Command, cfg *CmdConfig, command, verb, status string, update *bulkUpdate, fn func(bulkTarget, *bulkUpdate) error) error {
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return fmt.Errorf("failed to get concurrency flag: %w", err)
+	}
+	if concurrency < 1 {
+		return withExitCode(fmt.Errorf("--concurrency must be at least 1"), exitUsage)
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return fmt.Errorf("failed to get dry-run flag: %w", err)
+	}
+
+	var plan bulkPlan
+	var j *journal.Journal
+	var done map[int]bool
+	if isResume(cmd) {
+		if j, done, err = resumeJournal(cmd, cfg, command, &plan); err != nil {
+			return err
+		}
+	} else {
+		targets, fromStdin, err := bulkTargets(cmd, cfg)
+		if err != nil {
+			return err
+		}
+		if update != nil && update.UsedOn != nil {
+			if err := checkUsedOn(cfg, targets); err != nil {
+				return err
+			}
+		}
+		if dryRun || len(targets) == 0 {
+			return cfg.Printer.Print(targets)
+		}
+		ok, err := confirmBulk(cmd, cfg, targets, verb, fromStdin)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Cancelled.")
+			return nil
+		}
+
+		plan.Update = update
+		for _, m := range targets {
+			ref := refOf(m)
+			plan.Targets = append(plan.Targets, bulkTarget{Type: m.Type, ID: ref.id, FullAddress: ref.address})
+		}
+		if j, err = startJournal(cmd, cfg, command, plan); err != nil {
+			return err
+		}
+	}
+
+	stop := cfg.gracefulStop()
+	results, firstErr, journalErr := applyBulk(stop, j, plan, done, concurrency, status, fn)
+	runErr := bulkError(stop, results, len(done), firstErr)
+	if journalErr != nil {
+		runErr = journalErr
+	}
+	printErr := cfg.Printer.Print(results)
+	if err := finishJournal(cmd, command, j, runErr == nil); err != nil && runErr == nil {
+		runErr = err
+	}
+	if printErr != nil {
+		return printErr
+	}
+	return runErr
+}
+
+// checkUsedOn rejects a selection with custom domain masks, which have no
+// used_on field.
+func checkUsedOn(cfg *CmdConfig, targets []output.CombinedMask) error {
+	var custom []string
+	for _, m := range targets {
+		if m.Type == "custom" {
+			custom = append(custom, cfg.Printer.Redact(refOf(m).address))
+		}
+	}
+	if len(custom) == 0 {
+		return nil
+	}
+	return withExitCode(fmt.Errorf("--used-on only applies to random masks, but custom domain masks are selected: %s (use --random to select only random masks)", strings.Join(custom, ", ")), exitUsage)
+}
+
+// applyBulk runs fn on at most concurrency of the masks not yet done at a
+// time, recording each outcome in j. It returns the results in the order of
+// the plan, the first error and the first journal error. Masks not started
+// when stop is done or the journal fails are skipped.
+func applyBulk(stop context.Context, j *journal.Journal, plan bulkPlan, done map[int]bool, concurrency int, status string, fn func(bulkTarget, *bulkUpdate) error) ([]output.BulkResult, error, error) {
+	ctx, cancel := context.WithCancel(stop)
+	defer cancel()
+
+	var results []output.BulkResult
+	var items []int
+	for i, t := range plan.Targets {
+		if !done[i] {
+			results = append(results, output.BulkResult{Type: t.Type, ID: t.ID, FullAddress: t.FullAddress, Status: "skipped"})
+			items = append(items, i)
+		}
+	}
+	errs := make([]error, len(results))
+	var journalErr error
+	var once sync.Once
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for n, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(n, item int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			entry := journal.Entry{Item: item, Done: true}
+			if err := fn(plan.Targets[item], plan.Update); err != nil {
+				msg := errorMessage(err)
+				results[n].Status, results[n].Error = "failed", &msg
+				errs[n] = err
+				entry = journal.Entry{Item: item, Error: msg}
+			} else {
+				results[n].Status = status
+			}
+			if err := j.Record(entry); err != nil {
+				once.Do(func() {
+					journalErr = err
+					cancel()
+				})
+			}
+		}(n, item)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return results, err, journalErr
+		}
+	}
+	return results, nil, journalErr
+}
+
+// bulkError summarizes failed and skipped masks. done is the number of
+// masks changed by earlier runs of a resumed journal.
+func bulkError(ctx context.Context, results []output.BulkResult, done int, firstErr error) error {
+	var failed, skipped int
+	for _, r := range results {
+		switch r.Status {
+		case "failed":
+			failed++
+		case "skipped":
+			skipped++
+		}
+	}
+	return failureError(ctx, "masks", len(results)+done, failed, skipped, firstErr)
+}
+
+// failureError reports failed and skipped items out of total. If every
+// item failed, the first error decides the exit code; if only some did, it
+// is 8.
+func failureError(ctx context.Context, noun string, total, failed, skipped int, firstErr error) error {
+	switch {
+	case failed+skipped == 0:
+		return nil
+	case skipped > 0:
+		err := fmt.Errorf("interrupted with %d of %d %s not done: %w", skipped, total, noun, ctx.Err())
+		if failed+skipped < total {
+			return withExitCode(err, exitPartialFailure)
+		}
+		return err
+	case failed == total:
+		return fmt.Errorf("all %d %s failed: %w", failed, noun, firstErr)
+	}
+	return withExitCode(fmt.Errorf("%d of %d %s failed", failed, total, noun), exitPartialFailure)
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/journal"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRelay serves masks from memory. Calls named in fail are rejected with
+// failWith, or a 400 if it is nil. Methods the tests do not need panic
+// through the nil embedded interface.
+type fakeRelay struct {
+	api.RelayAPI
+
+	mu       sync.Mutex
+	relay    []api.RelayAddress
+	domain   []api.DomainAddress
+	fail     map[string]bool
+	failWith *api.APIError
+	calls    []string
+}
+
+func newFakeRelay() *fakeRelay {
+	return &fakeRelay{
+		relay: []api.RelayAddress{
+			{ID: 101, Address: "abc123", FullAddress: "abc123@mozmail.com", Description: "Shopping"},
+			{ID: 102, Address: "news77", FullAddress: "news77@mozmail.com", Description: "Newsletter"},
+		},
+		domain: []api.DomainAddress{
+			{ID: 101, Address: "shop", FullAddress: "shop@me.mozmail.com", Description: "Shopping"},
+		},
+		fail: make(map[string]bool),
+	}
+}
+
+func (f *fakeRelay) call(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, name)
+	if f.fail[name] {
+		if f.failWith != nil {
+			return f.failWith
+		}
+		return &api.APIError{StatusCode: 400, Body: `{"detail": "Rejected."}`}
+	}
+	return nil
+}
+
+func (f *fakeRelay) ListRelayAddresses() ([]api.RelayAddress, error) {
+	return f.relay, nil
+}
+
+func (f *fakeRelay) ListDomainAddresses() ([]api.DomainAddress, error) {
+	return f.domain, nil
+}
+
+func (f *fakeRelay) UpdateRelayAddress(id int, req api.UpdateRelayAddressRequest) (*api.RelayAddress, error) {
+	if err := f.call(fmt.Sprintf("update random:%d", id)); err != nil {
+		return nil, err
+	}
+	return &api.RelayAddress{ID: id}, nil
+}
+
+func (f *fakeRelay) UpdateDomainAddress(id int, req api.UpdateDomainAddressRequest) (*api.DomainAddress, error) {
+	if err := f.call(fmt.Sprintf("update custom:%d", id)); err != nil {
+		return nil, err
+	}
+	return &api.DomainAddress{ID: id}, nil
+}
+
+func (f *fakeRelay) DeleteRelayAddress(id int) error {
+	return f.call(fmt.Sprintf("delete random:%d", id))
+}
+
+func (f *fakeRelay) DeleteDomainAddress(id int) error {
+	return f.call(fmt.Sprintf("delete custom:%d", id))
+}
+
+func (f *fakeRelay) CreateRelayAddress(req api.CreateRelayAddressRequest) (*api.RelayAddress, error) {
+	if err := f.call("create random"); err != nil {
+		return nil, err
+	}
+	return &api.RelayAddress{ID: 201, Description: req.Description}, nil
+}
+
+func (f *fakeRelay) UpdateInboundContact(id int, req api.UpdateInboundContactRequest) (*api.InboundContact, error) {
+	if err := f.call(fmt.Sprintf("contact %d", id)); err != nil {
+		return nil, err
+	}
+	return &api.InboundContact{ID: id, Blocked: *req.Blocked}, nil
+}
+
+func (f *fakeRelay) UpdateRelayNumber(id int, req api.UpdateRelayNumberRequest) (*api.RelayNumber, error) {
+	if err := f.call(fmt.Sprintf("phone %d", id)); err != nil {
+		return nil, err
+	}
+	return &api.RelayNumber{ID: id, Enabled: *req.Enabled}, nil
+}
+
+func (f *fakeRelay) GetProfiles() ([]api.Profile, error) {
+	if err := f.call("profiles"); err != nil {
+		return nil, err
+	}
+	return []api.Profile{{ID: 1, HasPremium: true}}, nil
+}
+
+func (f *fakeRelay) ListUsers() ([]api.User, error) {
+	return []api.User{{Email: "me@example.com"}}, nil
+}
+
+func (f *fakeRelay) ListRelayNumbers() ([]api.RelayNumber, error) {
+	if err := f.call("phones"); err != nil {
+		return nil, err
+	}
+	return []api.RelayNumber{}, nil
+}
+
+func (f *fakeRelay) ListInboundContacts() ([]api.InboundContact, error) {
+	return []api.InboundContact{}, nil
+}
+
+func testConfig(t *testing.T, client api.RelayAPI) *CmdConfig {
+	t.Helper()
+	printer, err := output.NewPrinter(output.FormatText, output.WithColor(output.ColorNever))
+	require.NoError(t, err)
+	cfg := &CmdConfig{Client: client, Printer: printer, APIKey: "test-key"}
+	cfg.Ctx, cfg.Cancel = context.WithCancel(context.Background())
+	cfg.stop, cfg.stopFunc = context.WithCancel(cfg.Ctx)
+	t.Cleanup(cfg.Cancel)
+	return cfg
+}
+
+// bulkTestCmd returns a command with the flags of 'masks update', parsed
+// from args.
+func bulkTestCmd(t *testing.T, stdin string, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "update"}
+	cmd.Flags().Bool("random", true, "")
+	cmd.Flags().Bool("force", false, "")
+	addBulkFlags(cmd, "Update")
+	require.NoError(t, cmd.ParseFlags(args))
+	cmd.SetIn(strings.NewReader(stdin))
+	return cmd
+}
+
+func targetRefs(targets []output.CombinedMask) []string {
+	refs := make([]string, len(targets))
+	for i, m := range targets {
+		refs[i] = refOf(m).String()
+	}
+	return refs
+}
+
+func TestBulkTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		stdin     string
+		want      []string
+		fromStdin bool
+	}{
+		{"numeric IDs are random", []string{"--ids", "101"}, "", []string{"random:101"}, false},
+		{"typed IDs", []string{"--ids", "custom:101,random:102"}, "", []string{"custom:101", "random:102"}, false},
+		{"numeric IDs with --random=false", []string{"--ids", "101", "--random=false"}, "", []string{"custom:101"}, false},
+		{"addresses", []string{"--ids", "news77@mozmail.com,shop@me.mozmail.com"}, "", []string{"random:102", "custom:101"}, false},
+		{"local part and description", []string{"--ids", "shop,newsletter"}, "", []string{"custom:101", "random:102"}, false},
+		{"duplicates", []string{"--ids", "101,random:101,abc123@mozmail.com"}, "", []string{"random:101"}, false},
+		{"stdin", []string{"--ids", "-"}, "102\n\n  custom:101  \n", []string{"random:102", "custom:101"}, true},
+		{"filter", []string{"--filter", "type=custom"}, "", []string{"custom:101"}, false},
+		{"filter with --random", []string{"--filter", "id=101", "--random"}, "", []string{"random:101"}, false},
+		{"filter matching nothing", []string{"--filter", "id=5"}, "", []string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, newFakeRelay())
+			targets, fromStdin, err := bulkTargets(bulkTestCmd(t, tt.stdin, tt.args...), cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, targetRefs(targets))
+			assert.Equal(t, tt.fromStdin, fromStdin)
+		})
+	}
+}
+
+func TestBulkTargets_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantErr  string
+		wantCode exitCode
+	}{
+		{"missing IDs", []string{"--ids", "101,999,custom:102"}, "", "no mask found for 999, custom:102", exitNotFound},
+		{"no match", []string{"--ids", "nothing@mozmail.com"}, "", `no mask matches "nothing@mozmail.com"`, exitNotFound},
+		{"ambiguous", []string{"--ids", "shopping"}, "", `"shopping" matches 2 masks`, exitUsage},
+		{"conflicting type", []string{"--ids", "custom:101", "--random"}, "", "custom:101", exitUsage},
+		{"empty stdin", []string{"--ids", "-"}, "\n", "no masks given with --ids", exitUsage},
+		{"invalid filter", []string{"--filter", "nope=1"}, "", "nope", exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, newFakeRelay())
+			_, _, err := bulkTargets(bulkTestCmd(t, tt.stdin, tt.args...), cfg)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantCode, classifyError(err))
+		})
+	}
+}
+
+func TestCheckUsedOn(t *testing.T) {
+	cfg := testConfig(t, newFakeRelay())
+	masks, err := listCombinedMasks(cfg, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, checkUsedOn(cfg, masks[:2]))
+	err = checkUsedOn(cfg, masks)
+	assert.ErrorContains(t, err, "custom domain masks are selected: shop@me.mozmail.com")
+	assert.Equal(t, exitUsage, classifyError(err))
+}
+
+func testPlan() bulkPlan {
+	return bulkPlan{Targets: []bulkTarget{
+		{Type: "random", ID: 101, FullAddress: "abc123@mozmail.com"},
+		{Type: "random", ID: 102, FullAddress: "news77@mozmail.com"},
+		{Type: "custom", ID: 101, FullAddress: "shop@me.mozmail.com"},
+	}}
+}
+
+func testJournal(t *testing.T, command string, plan interface{}) (*journal.Journal, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := journal.Create(path, journal.Header{Command: command}, plan)
+	require.NoError(t, err)
+	return j, path
+}
+
+func deleteWith(client *fakeRelay) func(bulkTarget, *bulkUpdate) error {
+	return func(target bulkTarget, _ *bulkUpdate) error {
+		if target.Type == "random" {
+			return client.DeleteRelayAddress(target.ID)
+		}
+		return client.DeleteDomainAddress(target.ID)
+	}
+}
+
+func TestApplyBulk_PartialFailure(t *testing.T) {
+	client := newFakeRelay()
+	client.fail["delete random:102"] = true
+	j, path := testJournal(t, "masks delete", testPlan())
+
+	results, firstErr, journalErr := applyBulk(context.Background(), j, testPlan(), nil, 2, "deleted", deleteWith(client))
+	require.NoError(t, journalErr)
+	require.NoError(t, j.Close())
+
+	err := bulkError(context.Background(), results, 0, firstErr)
+	assert.EqualError(t, err, "1 of 3 masks failed")
+	assert.Equal(t, exitPartialFailure, classifyError(err))
+
+	var buf bytes.Buffer
+	require.NoError(t, testConfig(t, client).Printer.Fprint(&buf, results))
+	assert.Equal(t, `TYPE    ID   ADDRESS              STATUS   ERROR
+random  101  abc123@mozmail.com   deleted  -
+random  102  news77@mozmail.com   failed   Rejected.
+custom  101  shop@me.mozmail.com  deleted  -
+`, buf.String())
+
+	_, done, err := journal.Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true, 2: true}, done)
+}
+
+func TestApplyBulk_AllFailed(t *testing.T) {
+	client := newFakeRelay()
+	client.fail["delete random:101"] = true
+	client.fail["delete random:102"] = true
+	plan := bulkPlan{Targets: testPlan().Targets[:2]}
+	j, _ := testJournal(t, "masks delete", plan)
+	defer j.Close()
+
+	results, firstErr, _ := applyBulk(context.Background(), j, plan, nil, 4, "deleted", deleteWith(client))
+	err := bulkError(context.Background(), results, 0, firstErr)
+	assert.ErrorContains(t, err, "all 2 masks failed")
+	var apiErr *api.APIError
+	assert.ErrorAs(t, err, &apiErr, "the first error decides the exit code")
+}
+
+func TestApplyBulk_Resumed(t *testing.T) {
+	client := newFakeRelay()
+	client.fail["delete random:102"] = true
+	j, _ := testJournal(t, "masks delete", testPlan())
+	defer j.Close()
+
+	done := map[int]bool{0: true, 2: true}
+	results, firstErr, _ := applyBulk(context.Background(), j, testPlan(), done, 4, "deleted", deleteWith(client))
+	require.Len(t, results, 1)
+	assert.Equal(t, 102, results[0].ID)
+	assert.Equal(t, []string{"delete random:102"}, client.calls)
+
+	err := bulkError(context.Background(), results, len(done), firstErr)
+	assert.EqualError(t, err, "1 of 3 masks failed", "masks done by earlier runs count as changed")
+	assert.Equal(t, exitPartialFailure, classifyError(err))
+}
+
+func TestApplyBulk_Stopped(t *testing.T) {
+	client := newFakeRelay()
+	stop, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	j, path := testJournal(t, "masks delete", testPlan())
+
+	fn := func(target bulkTarget, u *bulkUpdate) error {
+		// Stop after the first mask, which still completes.
+		cancel()
+		return deleteWith(client)(target, u)
+	}
+	results, firstErr, _ := applyBulk(stop, j, testPlan(), nil, 1, "deleted", fn)
+	require.NoError(t, j.Close())
+
+	statuses := make([]string, len(results))
+	for i, r := range results {
+		statuses[i] = r.Status
+	}
+	assert.Equal(t, []string{"deleted", "skipped", "skipped"}, statuses)
+	assert.Equal(t, []string{"delete random:101"}, client.calls)
+
+	err := bulkError(stop, results, 0, firstErr)
+	assert.EqualError(t, err, "interrupted with 2 of 3 masks not done: context canceled")
+	assert.Equal(t, exitPartialFailure, classifyError(err))
+
+	_, done, err := journal.Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true}, done)
+}
+
+func TestFailureError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	first := &api.APIError{StatusCode: 404, Body: "Not found."}
+
+	tests := []struct {
+		name                   string
+		ctx                    context.Context
+		total, failed, skipped int
+		wantErr                string
+		wantCode               exitCode
+	}{
+		{"none failed", context.Background(), 3, 0, 0, "", exitCode{}},
+		{"some failed", context.Background(), 3, 1, 0, "1 of 3 items failed", exitPartialFailure},
+		{"all failed", context.Background(), 2, 2, 0, "all 2 items failed: Not found.", exitNotFound},
+		{"all skipped", canceled, 2, 0, 2, "interrupted with 2 of 2 items not done: context canceled", exitGeneral},
+		{"some skipped", canceled, 3, 1, 1, "interrupted with 1 of 3 items not done: context canceled", exitPartialFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := failureError(tt.ctx, "items", tt.total, tt.failed, tt.skipped, first)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantCode, classifyError(err))
+		})
+	}
+}
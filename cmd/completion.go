@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionCacheTTL keeps completion fast while pressing tab
+	// repeatedly, without showing masks deleted minutes ago.
+	completionCacheTTL = time.Minute
+	completionTimeout  = 5 * time.Second
+
+	completionDirName = "completion"
+)
+
+// completionItem is a cached completion candidate. Alt is another value
+// accepted for the same item, such as a mask's address.
+type completionItem struct {
+	Value       string `json:"value"`
+	Alt         string `json:"alt,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// completionConfig prepares cfg the way PersistentPreRunE does, which cobra
+// skips when completing arguments. The key is resolved later, by
+// completionClient, and only if the cache cannot answer.
+func completionConfig(cmd *cobra.Command) (*CmdConfig, error) {
+	cfg := GetConfig(cmd)
+	if err := bindEnvFlags(cmd, cfg); err != nil {
+		return nil, err
+	}
+	if err := applyContext(cmd, cfg); err != nil {
+		return nil, err
+	}
+	cfg.APIKey, _ = cmd.Flags().GetString("key")
+	cfg.BaseURL, _ = cmd.Flags().GetString("base-url")
+	cfg.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	cfg.Ctx, cfg.Cancel = context.WithTimeout(cmd.Context(), completionTimeout)
+	return cfg, nil
+}
+
+// completionClient resolves the key and creates the client. It never
+// prompts for a passphrase.
+func completionClient(cmd *cobra.Command, cfg *CmdConfig) error {
+	cmd.SetIn(strings.NewReader(""))
+	return configureClient(cmd, cfg)
+}
+
+// cachedCompletions returns the completion items of kind for the configured
+// account, fetching them if the cache is missing or stale.
+func cachedCompletions(cmd *cobra.Command, kind string, fetch func(api.RelayAPI) ([]completionItem, error)) ([]completionItem, error) {
+	cfg, err := completionConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := completionCachePath(cmd, cfg, kind)
+	if err == nil {
+		if items, ok := readCompletionCache(path); ok {
+			return items, nil
+		}
+	}
+
+	if err := completionClient(cmd, cfg); err != nil {
+		return nil, err
+	}
+	items, err := fetch(cfg.Client)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := writeCompletionCache(path, items); err != nil {
+			cobra.CompDebugln(err.Error(), false)
+		}
+	}
+	return items, nil
+}
+
+// completionCachePath names the cache file after a hash of the base URL and
+// where the key comes from, so that accounts do not share completions and
+// the cache can be read without running a key command or unlocking the
+// credential store. A key given directly is hashed, not stored.
+func completionCachePath(cmd *cobra.Command, cfg *CmdConfig, kind string) (string, error) {
+	dir, err := config.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(cfg.BaseURL + "\x00" + completionKeySource(cmd, cfg)))
+	return filepath.Join(dir, completionDirName, hex.EncodeToString(sum[:8])+"-"+kind+".json"), nil
+}
+
+// completionKeySource identifies the key resolveAPIKey would use, in the
+// same order, without resolving it.
+func completionKeySource(cmd *cobra.Command, cfg *CmdConfig) string {
+	if cfg.APIKey != "" && !cfg.envFlags["key"] {
+		return "key\x00" + cfg.APIKey
+	}
+	if command, _ := cmd.Flags().GetString("key-cmd"); command != "" {
+		return "key-cmd\x00" + command
+	}
+	if path, _ := cmd.Flags().GetString("key-file"); path != "" {
+		return "key-file\x00" + path
+	}
+	if cfg.APIKey != "" {
+		return "key\x00" + cfg.APIKey
+	}
+	if cfg.Context != nil {
+		return "context\x00" + cfg.ContextName
+	}
+	return "store\x00" + credentialName(cfg)
+}
+
+// readCompletionCache returns the items cached at path, unless the cache is
+// missing, stale or unreadable.
+func readCompletionCache(path string) ([]completionItem, bool) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) >= completionCacheTTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var items []completionItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, false
+	}
+	return items, true
+}
+
+func writeCompletionCache(path string, items []completionItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create completion cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// clearCompletionCache removes the cached completions of every account.
+// The cache is keyed by where the key comes from, not by account, so it
+// must be cleared when logging in or out or editing a context changes the
+// key found there.
+func clearCompletionCache() error {
+	dir, err := config.CacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(dir, completionDirName)); err != nil {
+		return fmt.Errorf("failed to clear completion cache: %w", err)
+	}
+	return nil
+}
+
+// completeFirstArg completes the first argument of a command from the
+// items of kind. transform, if set, may rewrite or drop items.
+func completeFirstArg(kind string, fetch func(api.RelayAPI) ([]completionItem, error), transform func(*cobra.Command, completionItem) (completionItem, bool)) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		items, err := cachedCompletions(cmd, kind, fetch)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []cobra.Completion
+		for _, item := range items {
+			if transform != nil {
+				var ok bool
+				if item, ok = transform(cmd, item); !ok {
+					continue
+				}
+			}
+			description := strings.Join(strings.Fields(item.Description), " ")
+			if strings.HasPrefix(item.Value, toComplete) {
+				completions = append(completions, cobra.CompletionWithDesc(item.Value, description))
+			}
+			// Offer alternatives only once something is typed, so that an
+			// empty argument lists each item once.
+			if toComplete != "" && item.Alt != "" && strings.HasPrefix(strings.ToLower(item.Alt), strings.ToLower(toComplete)) {
+				completions = append(completions, cobra.CompletionWithDesc(item.Alt, item.Value))
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func fetchMaskCompletions(client api.RelayAPI) ([]completionItem, error) {
+	relayAddresses, err := client.ListRelayAddresses()
+	if err != nil {
+		return nil, err
+	}
+	domainAddresses, err := client.ListDomainAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]completionItem, 0, len(relayAddresses)+len(domainAddresses))
+	for _, m := range relayAddresses {
+		items = append(items, completionItem{
+			Value:       fmt.Sprintf("random:%d", m.ID),
+			Alt:         m.FullAddress,
+			Description: joinNonEmpty(m.FullAddress, m.Description),
+		})
+	}
+	for _, m := range domainAddresses {
+		items = append(items, completionItem{
+			Value:       fmt.Sprintf("custom:%d", m.ID),
+			Alt:         m.FullAddress,
+			Description: joinNonEmpty(m.FullAddress, m.Description),
+		})
+	}
+	return items, nil
+}
+
+// maskCompletion completes plain numeric IDs of the type selected by
+// --random, and type-qualified IDs otherwise.
+func maskCompletion(cmd *cobra.Command, item completionItem) (completionItem, bool) {
+	if !cmd.Flags().Changed("random") {
+		return item, true
+	}
+	random, _ := cmd.Flags().GetBool("random")
+	prefix := "custom:"
+	if random {
+		prefix = "random:"
+	}
+	id, ok := strings.CutPrefix(item.Value, prefix)
+	if !ok {
+		return item, false
+	}
+	item.Value = id
+	return item, true
+}
+
+func fetchPhoneCompletions(client api.RelayAPI) ([]completionItem, error) {
+	numbers, err := client.ListRelayNumbers()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]completionItem, 0, len(numbers))
+	for _, n := range numbers {
+		items = append(items, completionItem{Value: strconv.Itoa(n.ID), Description: joinNonEmpty(n.Number, n.Location)})
+	}
+	return items, nil
+}
+
+func fetchContactCompletions(client api.RelayAPI) ([]completionItem, error) {
+	contacts, err := client.ListInboundContacts()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]completionItem, 0, len(contacts))
+	for _, c := range contacts {
+		status := ""
+		if c.Blocked {
+			status = "blocked"
+		}
+		items = append(items, completionItem{Value: strconv.Itoa(c.ID), Description: joinNonEmpty(c.InboundNumber, status)})
+	}
+	return items, nil
+}
+
+func fetchForwardingNumberCompletions(client api.RelayAPI) ([]completionItem, error) {
+	phones, err := client.GetRealPhone()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]completionItem, 0, len(phones))
+	for _, p := range phones {
+		status := "unverified"
+		if p.Verified {
+			status = "verified"
+		}
+		items = append(items, completionItem{Value: strconv.Itoa(p.ID), Description: joinNonEmpty(p.Number, status)})
+	}
+	return items, nil
+}
+
+func joinNonEmpty(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, " - ")
+}
+
+func init() {
+	completeMasks := completeFirstArg("masks", fetchMaskCompletions, maskCompletion)
+	for _, c := range []*cobra.Command{masksGetCmd, masksUpdateCmd, masksDeleteCmd} {
+		c.ValidArgsFunction = completeMasks
+	}
+
+	phonesUpdateCmd.ValidArgsFunction = completeFirstArg("phones", fetchPhoneCompletions, nil)
+	contactsUpdateCmd.ValidArgsFunction = completeFirstArg("contacts", fetchContactCompletions, nil)
+
+	completeForwardingNumbers := completeFirstArg("forwarding-numbers", fetchForwardingNumberCompletions, nil)
+	for _, c := range []*cobra.Command{phonesForwardGetCmd, phonesForwardVerifyCmd, phonesForwardDeleteCmd} {
+		c.ValidArgsFunction = completeForwardingNumbers
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionKeySource(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		cfg  *CmdConfig
+		want string
+	}{
+		{"key flag", []string{"--key-cmd", "pass show relay"}, &CmdConfig{APIKey: "k1"}, "key\x00k1"},
+		{"key command", []string{"--key-cmd", "pass show relay", "--key-file", "/k"}, &CmdConfig{}, "key-cmd\x00pass show relay"},
+		{"key file", []string{"--key-file", "/k"}, &CmdConfig{}, "key-file\x00/k"},
+		{"key from the environment", []string{"--key-file", "/k"}, &CmdConfig{APIKey: "k1", envFlags: map[string]bool{"key": true}}, "key-file\x00/k"},
+		{"key from the environment only", nil, &CmdConfig{APIKey: "k1", envFlags: map[string]bool{"key": true}}, "key\x00k1"},
+		{"context", nil, &CmdConfig{ContextName: "work", Context: &config.Context{}}, "context\x00work"},
+		{"credential store", nil, &CmdConfig{ContextName: "work"}, "store\x00work"},
+		{"default credential", nil, &CmdConfig{}, "store\x00" + defaultCredentialName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "get"}
+			cmd.Flags().String("key-cmd", "", "")
+			cmd.Flags().String("key-file", "", "")
+			require.NoError(t, cmd.ParseFlags(tt.args))
+			assert.Equal(t, tt.want, completionKeySource(cmd, tt.cfg))
+		})
+	}
+}
+
+func TestMaskCompletion(t *testing.T) {
+	items := []completionItem{
+		{Value: "random:101", Alt: "abc123@mozmail.com"},
+		{Value: "custom:101", Alt: "shop@me.mozmail.com"},
+	}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"both types", nil, []string{"random:101", "custom:101"}},
+		{"random", []string{"--random"}, []string{"101"}},
+		{"custom", []string{"--random=false"}, []string{"101"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "get"}
+			cmd.Flags().Bool("random", false, "")
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			var values []string
+			for _, item := range items {
+				if item, ok := maskCompletion(cmd, item); ok {
+					values = append(values, item.Value)
+				}
+			}
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
+
+func TestCompletionCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir, err := config.CacheDir()
+	require.NoError(t, err)
+	path := filepath.Join(dir, completionDirName, "account-masks.json")
+
+	_, ok := readCompletionCache(path)
+	assert.False(t, ok, "missing cache")
+
+	items := []completionItem{{Value: "random:101", Alt: "abc123@mozmail.com", Description: "Shopping"}}
+	require.NoError(t, writeCompletionCache(path, items))
+	cached, ok := readCompletionCache(path)
+	require.True(t, ok)
+	assert.Equal(t, items, cached)
+
+	stale := time.Now().Add(-completionCacheTTL)
+	require.NoError(t, os.Chtimes(path, stale, stale))
+	_, ok = readCompletionCache(path)
+	assert.False(t, ok, "stale cache")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, ok = readCompletionCache(path)
+	assert.False(t, ok, "invalid cache")
+
+	require.NoError(t, clearCompletionCache())
+	assert.NoDirExists(t, filepath.Dir(path))
+	assert.NoError(t, clearCompletionCache(), "clearing a missing cache")
+}
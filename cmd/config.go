@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hastefuI/ffrelayctl/config"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage ffrelayctl configuration",
+	Long: `Manage named contexts in the ffrelayctl config file.
+
+Each context stores the settings for one Relay account or environment:
+  key       API key (stored in plain text, so the config file must be
+            readable only by you; prefer key-env)
+  key-env   Name of an environment variable holding the API key
+  key-cmd   Command whose first line of output is the API key
+  key-file  File containing the API key (must be readable only by you)
+  base-url  Base URL for the API
+  output    Default output format
+  timeout   HTTP request timeout (e.g., 15s, 2m)
+
+Flags and FFRELAYCTL_* environment variables take precedence over the
+selected context. The config file is read from $XDG_CONFIG_HOME/ffrelayctl/config.yaml
+unless --config or FFRELAYCTL_CONFIG is set.`,
+	Annotations: map[string]string{modeAnnotation: modeLocal},
+}
+
+var configGetContextsCmd = &cobra.Command{
+	Use:   "get-contexts",
+	Short: "List configured contexts",
+	Long: `List all contexts in the config file. The current context is marked with "*".
+
+Examples:
+  ffrelayctl config get-contexts`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+		file, err := loadConfigFile(cmd)
+		if err != nil {
+			return err
+		}
+
+		current := selectedContextName(cmd, file)
+		contexts := make([]output.ContextInfo, 0, len(file.Contexts))
+		for _, name := range file.ContextNames() {
+			ctx := file.Contexts[name]
+			contexts = append(contexts, output.ContextInfo{
+				Current:   name == current,
+				Name:      name,
+				BaseURL:   ctx.BaseURL,
+				Output:    ctx.Output,
+				Timeout:   ctx.Timeout,
+				KeySource: ctx.KeySource(),
+			})
+		}
+		return cfg.Printer.Print(contexts)
+	},
+}
+
+var configCurrentContextCmd = &cobra.Command{
+	Use:   "current-context",
+	Short: "Show the current context",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := loadConfigFile(cmd)
+		if err != nil {
+			return err
+		}
+		if file.CurrentContext == "" {
+			return fmt.Errorf("current context is not set")
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), file.CurrentContext)
+		return nil
+	},
+}
+
+var configUseContextCmd = &cobra.Command{
+	Use:   "use-context <name>",
+	Short: "Set the current context",
+	Long: `Set the context used when --context is not given.
+
+Examples:
+  ffrelayctl config use-context personal`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := loadConfigFile(cmd)
+		if err != nil {
+			return err
+		}
+		if err := file.UseContext(args[0]); err != nil {
+			return err
+		}
+		if err := file.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Switched to context %q.\n", args[0])
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in a context",
+	Long: `Set a value in the current context, or in the context named by --context.
+
+The context is created if it does not exist. The first context created
+becomes the current context.
+
+Examples:
+  ffrelayctl config set key-env RELAY_PERSONAL_KEY --context personal
+  ffrelayctl config set key-cmd "pass show firefox-relay" --context work
+  ffrelayctl config set base-url https://relay.allizom.org --context staging
+  ffrelayctl config set output json
+  ffrelayctl config set timeout 1m`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := loadConfigFile(cmd)
+		if err != nil {
+			return err
+		}
+
+		name := selectedContextName(cmd, file)
+		if name == "" {
+			return fmt.Errorf("no current context set.\nUse --context <name> to choose the context to configure")
+		}
+
+		key, value := args[0], args[1]
+		if key == config.KeyOutput {
+			if err := output.ValidateFormat(value); err != nil {
+				return err
+			}
+		}
+		if err := file.Set(name, key, value); err != nil {
+			return err
+		}
+		if err := file.Save(); err != nil {
+			return err
+		}
+		if err := clearCompletionCache(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Set %s in context %q.\n", key, name)
+		return nil
+	},
+}
+
+var configDeleteContextCmd = &cobra.Command{
+	Use:   "delete-context <name>",
+	Short: "Delete a context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := loadConfigFile(cmd)
+		if err != nil {
+			return err
+		}
+		if err := file.DeleteContext(args[0]); err != nil {
+			return err
+		}
+		if err := file.Save(); err != nil {
+			return err
+		}
+		if err := clearCompletionCache(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Deleted context %q.\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetContextsCmd)
+	configCmd.AddCommand(configCurrentContextCmd)
+	configCmd.AddCommand(configUseContextCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configDeleteContextCmd)
+	printsResults(configGetContextsCmd, []output.ContextInfo{})
+}
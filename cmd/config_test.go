@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFile_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	c := newCLI(t, newFakeRelay())
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte("contexts:\n  work:\n    base-url: https://relay.example\n"), 0o644))
+	_, stderr, status := c.run("config", "get-contexts", "--config", path)
+	assert.Equal(t, 0, status, "a readable config file without keys is fine: %s", stderr)
+
+	require.NoError(t, os.WriteFile(path, []byte("contexts:\n  work:\n    key: secret\n"), 0o644))
+	_, stderr, status = c.run("config", "get-contexts", "--config", path)
+	assert.Equal(t, exitGeneral.status, status)
+	assert.Contains(t, stderr, "config file stores an API key: insecure permissions 0644")
+
+	require.NoError(t, os.Chmod(path, 0o600))
+	_, stderr, status = c.run("config", "get-contexts", "--config", path)
+	assert.Equal(t, 0, status, stderr)
+}
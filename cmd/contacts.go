@@ -5,7 +5,6 @@ import (
 	"strconv"
 
 	"github.com/hastefuI/ffrelayctl/api"
-	"github.com/hastefuI/ffrelayctl/output"
 	"github.com/spf13/cobra"
 )
 
@@ -26,15 +25,36 @@ including phone numbers, call/text counts, and blocking status.
 Note: This feature requires a premium subscription with phone masks enabled.
 If you don't have a premium subscription, you'll receive a 404 error.
 
+` + filterHelp + `
+
 Examples:
-  ffrelayctl contacts list`,
+  ffrelayctl contacts list
+  ffrelayctl contacts list --filter 'not blocked and last_inbound_date>7d'
+  ffrelayctl contacts list --filter 'num_calls_blocked>0'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
+		expr, err := listFilter(cmd, api.InboundContact{})
+		if err != nil {
+			return err
+		}
+		if streamer, lw, ok := lineStreamer(cfg); ok {
+			return streamer.StreamInboundContacts(func(c api.InboundContact) error {
+				if ok, err := matches(expr, c); err != nil || !ok {
+					return err
+				}
+				return lw.Write(c)
+			})
+		}
+
 		contacts, err := cfg.Client.ListInboundContacts()
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,contacts)
+		contacts, err = filterItems(expr, contacts)
+		if err != nil {
+			return err
+		}
+		return cfg.Printer.Print(contacts)
 	},
 }
 
@@ -81,14 +101,17 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,contact)
+		return cfg.Printer.Print(contact)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(contactsCmd)
 	contactsCmd.AddCommand(contactsListCmd)
+	addFilterFlag(contactsListCmd)
 	contactsCmd.AddCommand(contactsUpdateCmd)
+	printsResults(contactsListCmd, []api.InboundContact{})
+	printsResults(contactsUpdateCmd, api.InboundContact{})
 
 	contactsUpdateCmd.Flags().Bool("block", false, "Block this contact")
 	contactsUpdateCmd.Flags().Bool("unblock", false, "Unblock this contact")
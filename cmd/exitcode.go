@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+// exitCode identifies a class of failure by a name, reported in JSON
+// errors, and the process exit status.
+type exitCode struct {
+	name   string
+	status int
+}
+
+var (
+	exitGeneral         = exitCode{"error", 1}
+	exitUsage           = exitCode{"usage", 2}
+	exitUnauthorized    = exitCode{"unauthorized", 3}
+	exitNotFound        = exitCode{"not_found", 4}
+	exitPremiumRequired = exitCode{"premium_required", 5}
+	exitRateLimited     = exitCode{"rate_limited", 6}
+	exitNetwork         = exitCode{"network", 7}
+	exitPartialFailure  = exitCode{"partial_failure", 8}
+)
+
+const exitCodesHelp = `Exit codes:
+  0  Success
+  1  Other error
+  2  Invalid usage: unknown command, flag or argument
+  3  Missing or rejected API key
+  4  Not found
+  5  Premium subscription required
+  6  Rate limited by the API
+  7  Network error: the API could not be reached
+  8  Partial failure: some operations failed`
+
+// codedError sets the exit code of errors that cannot be classified by
+// their cause.
+type codedError struct {
+	err  error
+	code exitCode
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(err error, code exitCode) error {
+	return &codedError{err: err, code: code}
+}
+
+func classifyError(err error) exitCode {
+	var coded *codedError
+	var urlErr *url.Error
+	var netErr net.Error
+	var optErr *output.OptionError
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.As(err, &optErr):
+		return exitUsage
+	case errors.Is(err, api.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, api.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, api.ErrPremiumRequired):
+		return exitPremiumRequired
+	case errors.Is(err, api.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, context.Canceled):
+		return exitGeneral
+	case errors.As(err, &urlErr), errors.As(err, &netErr):
+		return exitNetwork
+	}
+	return exitGeneral
+}
+
+type errorObject struct {
+	Code     string `json:"code"`
+	ExitCode int    `json:"exit_code"`
+	Status   *int   `json:"status"`
+	Message  string `json:"message"`
+}
+
+// reportError writes err to w, as a JSON object for JSON output formats,
+// and returns the exit status.
+func reportError(w io.Writer, format string, cmd *cobra.Command, err error) int {
+	code := classifyError(err)
+
+	if format == output.FormatJSON || output.IsLineFormat(format) {
+		obj := errorObject{Code: code.name, ExitCode: code.status, Message: errorMessage(err)}
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) {
+			obj.Status = &apiErr.StatusCode
+		}
+		data, _ := json.Marshal(obj)
+		fmt.Fprintln(w, string(data))
+		return code.status
+	}
+
+	fmt.Fprintln(w, "Error:", errorMessage(err))
+	if code == exitUsage && cmd != nil {
+		fmt.Fprintf(w, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return code.status
+}
+
+// errorMessage returns the message of err with the detail of an API error
+// in place of its response body.
+func errorMessage(err error) string {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	return strings.Replace(err.Error(), apiErr.Error(), apiErr.Message(), 1)
+}
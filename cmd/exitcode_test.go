@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	apiErr := func(status int, body string) error {
+		return &api.APIError{StatusCode: status, Body: body}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want exitCode
+	}{
+		{"401", apiErr(401, `{"detail": "Invalid token."}`), exitUnauthorized},
+		{"403", apiErr(403, `{"detail": "You do not have permission."}`), exitUnauthorized},
+		{"403 for premium", apiErr(403, `{"detail": "Requires a Premium subscription."}`), exitPremiumRequired},
+		{"402", apiErr(402, ""), exitPremiumRequired},
+		{"404", apiErr(404, `{"detail": "Not found."}`), exitNotFound},
+		{"wrapped 404", fmt.Errorf("failed to fetch: %w", apiErr(404, "")), exitNotFound},
+		{"429", apiErr(429, ""), exitRateLimited},
+		{"400", apiErr(400, ""), exitGeneral},
+		{"500", apiErr(500, ""), exitGeneral},
+		{"503", apiErr(503, ""), exitGeneral},
+		{"usage", withExitCode(errors.New("bad flag"), exitUsage), exitUsage},
+		{"explicit code wins", withExitCode(apiErr(404, ""), exitPartialFailure), exitPartialFailure},
+		{"printer option", &output.OptionError{Err: errors.New(`unknown field "bogus"`)}, exitUsage},
+		{"network", &url.Error{Op: "Get", URL: "https://relay.firefox.com", Err: errors.New("connection refused")}, exitNetwork},
+		{"canceled", fmt.Errorf("request failed: %w", context.Canceled), exitGeneral},
+		{"other", errors.New("boom"), exitGeneral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyError(tt.err))
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	err := fmt.Errorf("failed to fetch masks: %w", &api.APIError{StatusCode: 404, Body: `{"detail": "Not found."}`})
+
+	for _, format := range []string{output.FormatJSON, output.FormatJSONL, output.FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf strings.Builder
+			status := reportError(&buf, format, nil, err)
+			assert.Equal(t, exitNotFound.status, status)
+			assert.JSONEq(t, `{"code": "not_found", "exit_code": 4, "status": 404, "message": "failed to fetch masks: Not found."}`, buf.String())
+			assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "one line")
+		})
+	}
+
+	t.Run("no status", func(t *testing.T) {
+		var buf strings.Builder
+		status := reportError(&buf, output.FormatJSON, nil, withExitCode(errors.New("bad flag"), exitUsage))
+		assert.Equal(t, exitUsage.status, status)
+		assert.JSONEq(t, `{"code": "usage", "exit_code": 2, "status": null, "message": "bad flag"}`, buf.String())
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var buf strings.Builder
+		status := reportError(&buf, output.FormatText, nil, err)
+		assert.Equal(t, exitNotFound.status, status)
+		assert.Equal(t, "Error: failed to fetch masks: Not found.\n", buf.String())
+	})
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    exitCode
+		wantErr string
+	}{
+		{"unknown command", nil, []string{"bogus"}, exitUsage, `unknown command "bogus" for "ffrelayctl"`},
+		{"unknown subcommand", nil, []string{"masks", "bogus"}, exitUsage, `unknown command "bogus" for "ffrelayctl masks"`},
+		{"unknown nested subcommand", nil, []string{"phones", "forward", "bogus"}, exitUsage, `unknown command "bogus" for "ffrelayctl phones forward"`},
+		{"unknown flag", nil, []string{"masks", "list", "--bogus"}, exitUsage, "unknown flag: --bogus"},
+		{"missing argument", nil, []string{"api", "GET"}, exitUsage, "accepts 2 arg(s)"},
+		{"flag group", nil, []string{"api", "GET", "profiles/", "--input", "-", "-f", "a=b", "--key", "k"}, exitUsage, "if any flags in the group [input field] are set"},
+		{"invalid environment variable", map[string]string{"FFRELAYCTL_TIMEOUT": "bogus"}, []string{"masks", "list", "--key", "k"}, exitUsage, "invalid value for FFRELAYCTL_TIMEOUT"},
+		{"unknown context", nil, []string{"masks", "list", "--context", "nope"}, exitUsage, `context "nope" not found`},
+		{"unsupported format", nil, []string{"export", "-o", "csv", "--key", "k"}, exitUsage, "csv output is not supported for this command"},
+		{"no API key", nil, []string{"masks", "list"}, exitUnauthorized, "no API key provided"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeRelay()
+			c := newCLI(t, client)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, stderr, status := c.run(tt.args...)
+			assert.Equal(t, tt.want.status, status, stderr)
+			assert.Contains(t, stderr, tt.wantErr)
+			assert.Empty(t, client.calls, "no requests are made")
+		})
+	}
+}
+
+func TestExitCodes_GroupCommandHelp(t *testing.T) {
+	stdout, stderr, status := newCLI(t, newFakeRelay()).run("masks")
+	require.Equal(t, 0, status, stderr)
+	assert.Contains(t, stdout, "Manage your Firefox Relay email masks.")
+}
+
+func TestExitCodes_APIError(t *testing.T) {
+	client := newFakeRelay()
+	client.fail["delete random:101"] = true
+	client.failWith = &api.APIError{StatusCode: 404, Body: `{"detail": "Not found."}`}
+	c := newCLI(t, client)
+
+	_, stderr, status := c.run("masks", "delete", "101", "--random", "--force", "--key", "k")
+	assert.Equal(t, exitNotFound.status, status)
+	assert.Equal(t, "Error: Not found.\n", stderr)
+
+	_, stderr, status = c.run("masks", "delete", "101", "--random", "--force", "--key", "k", "-o", "json")
+	assert.Equal(t, exitNotFound.status, status)
+	assert.JSONEq(t, `{"code": "not_found", "exit_code": 4, "status": 404, "message": "Not found."}`, stderr)
+}
+
+func TestExitCodes_ExportFetchFails(t *testing.T) {
+	client := newFakeRelay()
+	client.fail["phones"] = true
+	client.failWith = &api.APIError{StatusCode: 403, Body: `{"detail": "Requires a Premium subscription."}`}
+
+	stdout, stderr, status := newCLI(t, client).run("export", "-o", "json", "--key", "k")
+	assert.Equal(t, exitPremiumRequired.status, status, "the failed fetch decides the exit code")
+	assert.Empty(t, stdout, "nothing is exported")
+	assert.JSONEq(t, `{"code": "premium_required", "exit_code": 5, "status": 403, "message": "failed to export data: failed to fetch relay numbers: Requires a Premium subscription."}`, stderr)
+}
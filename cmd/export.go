@@ -2,13 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
-	"github.com/hastefuI/ffrelayctl/api"
 	"github.com/hastefuI/ffrelayctl/output"
 	"github.com/spf13/cobra"
 )
 
+// exportFetches is the number of concurrent requests made by export.
+const exportFetches = 5
+
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export all Firefox Relay account data",
@@ -17,28 +20,27 @@ var exportCmd = &cobra.Command{
 This command fetches all masks, phones, profiles, and contacts from a
 Firefox Relay account for backup purposes.
 
+With --output html or --output markdown, a readable account report is written
+instead: a summary, profile statistics, and tables of masks grouped by type,
+phone masks and inbound contacts. The HTML report is a single self-contained
+file suitable for sharing.
+
 Examples:
   ffrelayctl export
-  ffrelayctl export > ffrelay-backup.json`,
+  ffrelayctl export > ffrelay-backup.json
+  ffrelayctl export --output html > relay-report.html
+  ffrelayctl export --output markdown > relay-report.md`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
 
-		type exportData struct {
-			Masks    []output.CombinedMask `json:"masks"`
-			Phones   []api.RelayNumber     `json:"phones"`
-			Profiles []api.Profile         `json:"profiles"`
-			Contacts []api.InboundContact  `json:"contacts"`
-			Users    []api.User            `json:"users"`
-		}
-
 		var (
 			wg     sync.WaitGroup
 			mu     sync.Mutex
 			errors []error
-			result exportData
+			result = output.AccountExport{SchemaVersion: cfg.Printer.OutputVersion()}
 		)
 
-		wg.Add(5)
+		wg.Add(exportFetches)
 
 		go func() {
 			defer wg.Done()
@@ -129,16 +131,20 @@ Examples:
 		wg.Wait()
 
 		if len(errors) > 0 {
-			for _, err := range errors {
-				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+			messages := make([]string, 0, len(errors))
+			for _, err := range errors[1:] {
+				messages = append(messages, "; "+err.Error())
 			}
-			return fmt.Errorf("failed to export data: %d error(s) occurred", len(errors))
+			// Only the first error is wrapped, so that it decides the exit
+			// code and the reported HTTP status.
+			return fmt.Errorf("failed to export data: %w%s", errors[0], strings.Join(messages, ""))
 		}
 
-		return output.Print(cfg.OutputFormat, result)
+		return cfg.Printer.Print(result)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
+	printsResults(exportCmd, output.AccountExport{})
 }
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hastefuI/ffrelayctl/filter"
+	"github.com/spf13/cobra"
+)
+
+const filterHelp = `The --filter flag selects items by their JSON fields (see --output json):
+  enabled=false and num_spam>0     Comparisons with =, !=, <, <=, >, >=
+  description~news                 Contains, ignoring case (!~ negates)
+  description~/^shop(ping)?$/      Matches a regular expression, ignoring case
+  created_at<2025-01-01            Before a date
+  last_used_at<90d                 More than 90 days ago (units: s m h d w y)
+  last_used_at>90d                 Less than 90 days ago
+  not blocked or last_used_at=null Boolean fields, null, and, or, not, ( )
+For dates and ages alike, < means earlier and > means later.
+Quote values containing spaces or operators, e.g. description="gift cards".`
+
+func addFilterFlag(cmd *cobra.Command) {
+	cmd.Flags().String("filter", "", `Only list items matching an expression (e.g., "enabled=false and num_spam>0")`)
+}
+
+// listFilter parses the --filter flag of cmd and checks its fields against
+// samples of the listed types. It returns nil if no filter is set.
+func listFilter(cmd *cobra.Command, samples ...interface{}) (*filter.Expr, error) {
+	src, err := cmd.Flags().GetString("filter")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter flag: %w", err)
+	}
+	if src == "" {
+		return nil, nil
+	}
+
+	expr, err := filter.Parse(src)
+	if err != nil {
+		return nil, withExitCode(err, exitUsage)
+	}
+	if err := expr.Validate(samples...); err != nil {
+		return nil, withExitCode(err, exitUsage)
+	}
+	return expr, nil
+}
+
+// matches reports whether v is selected by expr. A nil expr selects
+// everything.
+func matches(expr *filter.Expr, v interface{}) (bool, error) {
+	if expr == nil {
+		return true, nil
+	}
+	return expr.Match(v)
+}
+
+// filterItems returns the items selected by expr.
+func filterItems[T any](expr *filter.Expr, items []T) ([]T, error) {
+	if expr == nil {
+		return items, nil
+	}
+	selected := make([]T, 0, len(items))
+	for _, item := range items {
+		ok, err := expr.Match(item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, item)
+		}
+	}
+	return selected, nil
+}
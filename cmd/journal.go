@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/config"
+	"github.com/hastefuI/ffrelayctl/credentials"
+	"github.com/hastefuI/ffrelayctl/journal"
+	"github.com/spf13/cobra"
+)
+
+const journalDirName = "journals"
+
+const journalHelp = `Progress is recorded in a journal. If the run is interrupted or some items
+fail, the journal is kept and --resume <journal> runs the items that did
+not succeed, without selecting or asking again. An interrupt lets requests
+in progress finish before exiting; a second one cancels them.`
+
+func addJournalFlags(cmd *cobra.Command) {
+	cmd.Flags().String("journal", "", "Record progress in this file and keep it (default: a file in the cache directory, removed if all items succeed)")
+	cmd.Flags().String("resume", "", "Continue a run from its journal")
+	cmd.MarkFlagsMutuallyExclusive("journal", "resume")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "resume")
+}
+
+func isResume(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("resume")
+}
+
+// startJournal creates the journal of a new run of command, at the path
+// given with --journal or in the cache directory.
+func startJournal(cmd *cobra.Command, cfg *CmdConfig, command string, plan interface{}) (*journal.Journal, error) {
+	path, err := cmd.Flags().GetString("journal")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal flag: %w", err)
+	}
+	keep := path != ""
+	if !keep {
+		dir, err := config.CacheDir()
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s-%s-%d.jsonl", strings.ReplaceAll(command, " ", "-"), time.Now().Format("20060102-150405"), os.Getpid())
+		path = filepath.Join(dir, journalDirName, name)
+	}
+	baseURL, fingerprint := journalAccount(cfg)
+	header := journal.Header{Command: command, Keep: keep, BaseURL: baseURL, KeyFingerprint: fingerprint}
+	return journal.Create(path, header, plan)
+}
+
+// journalAccount identifies the account cfg acts on by its base URL and
+// key fingerprint.
+func journalAccount(cfg *CmdConfig) (string, string) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = api.DefaultBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/"), credentials.Fingerprint(cfg.APIKey)
+}
+
+// resumeJournal opens the journal given with --resume, checks that it was
+// written by command for the account cfg acts on and decodes its plan. It
+// returns the items that already succeeded.
+func resumeJournal(cmd *cobra.Command, cfg *CmdConfig, command string, plan interface{}) (*journal.Journal, map[int]bool, error) {
+	path, err := cmd.Flags().GetString("resume")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get resume flag: %w", err)
+	}
+	j, done, err := journal.Open(path)
+	if err != nil {
+		if errors.Is(err, journal.ErrInvalid) || errors.Is(err, os.ErrNotExist) {
+			return nil, nil, withExitCode(err, exitUsage)
+		}
+		return nil, nil, err
+	}
+	header := j.Header()
+	if header.Command != command {
+		j.Close()
+		return nil, nil, withExitCode(fmt.Errorf("%s is a journal of %q, not %q", path, header.Command, command), exitUsage)
+	}
+	baseURL, fingerprint := journalAccount(cfg)
+	if header.BaseURL != baseURL || header.KeyFingerprint != fingerprint {
+		j.Close()
+		return nil, nil, withExitCode(fmt.Errorf("%s was written for another account (%s, key %s), not %s with key %s", path, header.BaseURL, header.KeyFingerprint, baseURL, fingerprint), exitUsage)
+	}
+	if err := json.Unmarshal(header.Plan, plan); err != nil {
+		j.Close()
+		return nil, nil, fmt.Errorf("%s: invalid plan: %w", path, err)
+	}
+	return j, done, nil
+}
+
+// finishJournal closes j. Once every item succeeded the journal is removed,
+// unless it was asked for with --journal; otherwise it tells how to resume.
+func finishJournal(cmd *cobra.Command, command string, j *journal.Journal, complete bool) error {
+	if err := j.Close(); err != nil {
+		return err
+	}
+	if complete {
+		if j.Header().Keep {
+			return nil
+		}
+		if err := os.Remove(j.Path()); err != nil {
+			return fmt.Errorf("failed to remove journal: %w", err)
+		}
+		return nil
+	}
+	// The hint must work from any directory.
+	path, err := filepath.Abs(j.Path())
+	if err != nil {
+		path = j.Path()
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Progress saved. To retry the items that did not succeed, run:\n  ffrelayctl %s --resume %s\n", command, path)
+	return nil
+}
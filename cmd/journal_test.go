@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/journal"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinishJournal(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	t.Run("incomplete", func(t *testing.T) {
+		j, err := journal.Create("interrupted.jsonl", journal.Header{Command: "masks delete"}, testPlan())
+		require.NoError(t, err)
+		cmd := &cobra.Command{}
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+
+		require.NoError(t, finishJournal(cmd, "masks delete", j, false))
+		assert.Contains(t, stderr.String(), "ffrelayctl masks delete --resume "+filepath.Join(dir, "interrupted.jsonl")+"\n",
+			"the hint works from any directory")
+		assert.FileExists(t, "interrupted.jsonl")
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		j, err := journal.Create("done.jsonl", journal.Header{Command: "masks delete"}, testPlan())
+		require.NoError(t, err)
+		require.NoError(t, finishJournal(&cobra.Command{}, "masks delete", j, true))
+		assert.NoFileExists(t, "done.jsonl")
+	})
+
+	t.Run("complete and kept", func(t *testing.T) {
+		j, err := journal.Create("kept.jsonl", journal.Header{Command: "masks delete", Keep: true}, testPlan())
+		require.NoError(t, err)
+		require.NoError(t, finishJournal(&cobra.Command{}, "masks delete", j, true))
+		assert.FileExists(t, "kept.jsonl")
+	})
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+const maskRefHelp = `A mask can be given as:
+  12345               Numeric ID (the type is chosen with --random)
+  random:12345        ID of a random mask
+  custom:12345        ID of a custom domain mask
+  abc123@mozmail.com  Full address
+  abc123              Local part of the address
+  "Airline tickets"   Description, if no address matches (ignoring case)`
+
+// maskRef identifies a mask. random is nil when only the numeric ID was
+// given and --random was not set.
+type maskRef struct {
+	id      int
+	random  *bool
+	address string
+}
+
+// isRandom reports whether ref is a random mask, using def when the type
+// is not known.
+func (ref maskRef) isRandom(def bool) bool {
+	if ref.random == nil {
+		return def
+	}
+	return *ref.random
+}
+
+// resolveMaskRef resolves a mask argument. Numeric and type-qualified IDs
+// are used as they are; addresses and descriptions are looked up in the
+// masks of the type selected by --random, or of both types.
+func resolveMaskRef(cmd *cobra.Command, cfg *CmdConfig, arg string) (maskRef, error) {
+	explicit, err := explicitRandom(cmd)
+	if err != nil {
+		return maskRef{}, err
+	}
+	if ref, ok, err := parseMaskID(arg, explicit); ok || err != nil {
+		return ref, err
+	}
+
+	masks, err := listCombinedMasks(cfg, explicit)
+	if err != nil {
+		return maskRef{}, err
+	}
+	return lookupMask(cfg, masks, arg)
+}
+
+// explicitRandom returns the value of --random, or nil if it was not set.
+func explicitRandom(cmd *cobra.Command) (*bool, error) {
+	if !cmd.Flags().Changed("random") {
+		return nil, nil
+	}
+	val, err := cmd.Flags().GetBool("random")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get random flag: %w", err)
+	}
+	return &val, nil
+}
+
+// parseMaskID parses numeric and type-qualified IDs. It reports false for
+// other arguments, which name masks by address or description.
+func parseMaskID(arg string, explicit *bool) (maskRef, bool, error) {
+	if id, err := strconv.Atoi(arg); err == nil {
+		return maskRef{id: id, random: explicit}, true, nil
+	}
+	if kind, idText, ok := strings.Cut(arg, ":"); ok && (kind == "random" || kind == "custom") {
+		if id, err := strconv.Atoi(idText); err == nil {
+			random := kind == "random"
+			if explicit != nil && *explicit != random {
+				return maskRef{}, false, withExitCode(fmt.Errorf("%s conflicts with --random=%t", arg, *explicit), exitUsage)
+			}
+			return maskRef{id: id, random: &random}, true, nil
+		}
+	}
+	return maskRef{}, false, nil
+}
+
+// lookupMask finds the single mask among masks named by an address or
+// description.
+func lookupMask(cfg *CmdConfig, masks []output.CombinedMask, arg string) (maskRef, error) {
+	found := findMasks(masks, arg)
+	switch len(found) {
+	case 0:
+		return maskRef{}, withExitCode(fmt.Errorf("no mask matches %q", arg), exitNotFound)
+	case 1:
+		return found[0], nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%q matches %d masks; use one of these IDs:", arg, len(found))
+	for _, ref := range found {
+		fmt.Fprintf(&sb, "\n  %s  %s", ref, cfg.Printer.Redact(ref.address))
+	}
+	return maskRef{}, withExitCode(fmt.Errorf("%s", sb.String()), exitUsage)
+}
+
+func (ref maskRef) String() string {
+	switch {
+	case ref.random == nil:
+		return strconv.Itoa(ref.id)
+	case *ref.random:
+		return fmt.Sprintf("random:%d", ref.id)
+	}
+	return fmt.Sprintf("custom:%d", ref.id)
+}
+
+// findMasks matches arg against full addresses if it contains "@", and
+// otherwise against local parts, falling back to descriptions.
+func findMasks(masks []output.CombinedMask, arg string) []maskRef {
+	type candidate struct {
+		ref                             maskRef
+		fullAddress, local, description string
+	}
+	var candidates []candidate
+	for _, m := range masks {
+		switch mask := m.Mask.(type) {
+		case api.RelayAddress:
+			candidates = append(candidates, candidate{refOf(m), mask.FullAddress, mask.Address, mask.Description})
+		case api.DomainAddress:
+			candidates = append(candidates, candidate{refOf(m), mask.FullAddress, mask.Address, mask.Description})
+		}
+	}
+
+	match := func(field func(candidate) string) []maskRef {
+		var refs []maskRef
+		for _, c := range candidates {
+			if strings.EqualFold(field(c), arg) {
+				refs = append(refs, c.ref)
+			}
+		}
+		return refs
+	}
+
+	if strings.Contains(arg, "@") {
+		return match(func(c candidate) string { return c.fullAddress })
+	}
+	if refs := match(func(c candidate) string { return c.local }); len(refs) > 0 {
+		return refs
+	}
+	return match(func(c candidate) string { return c.description })
+}
+
+// refOf returns the reference of a listed mask.
+func refOf(m output.CombinedMask) maskRef {
+	random := m.Type == "random"
+	ref := maskRef{random: &random}
+	switch mask := m.Mask.(type) {
+	case api.RelayAddress:
+		ref.id, ref.address = mask.ID, mask.FullAddress
+	case api.DomainAddress:
+		ref.id, ref.address = mask.ID, mask.FullAddress
+	}
+	return ref
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestParseMaskID(t *testing.T) {
+	tests := []struct {
+		name     string
+		arg      string
+		explicit *bool
+		want     string
+		ok       bool
+	}{
+		{"bare ID", "101", nil, "101", true},
+		{"bare ID with --random", "101", boolPtr(true), "random:101", true},
+		{"bare ID with --random=false", "101", boolPtr(false), "custom:101", true},
+		{"random prefix", "random:101", nil, "random:101", true},
+		{"custom prefix", "custom:101", nil, "custom:101", true},
+		{"prefix agreeing with --random", "custom:101", boolPtr(false), "custom:101", true},
+		{"unknown prefix", "other:101", nil, "", false},
+		{"prefix without ID", "random:abc", nil, "", false},
+		{"address", "abc123@mozmail.com", nil, "", false},
+		{"description", "Shopping", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, ok, err := parseMaskID(tt.arg, tt.explicit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, ref.String())
+			}
+		})
+	}
+
+	_, _, err := parseMaskID("random:101", boolPtr(false))
+	assert.EqualError(t, err, "random:101 conflicts with --random=false")
+	assert.Equal(t, exitUsage, classifyError(err))
+}
+
+func TestMaskRef_IsRandom(t *testing.T) {
+	ref, _, err := parseMaskID("101", nil)
+	require.NoError(t, err)
+	assert.True(t, ref.isRandom(true), "a bare ID takes the default type")
+	assert.False(t, ref.isRandom(false))
+
+	ref, _, err = parseMaskID("custom:101", nil)
+	require.NoError(t, err)
+	assert.False(t, ref.isRandom(true))
+}
+
+func refStrings(refs []maskRef) []string {
+	out := make([]string, len(refs))
+	for i, ref := range refs {
+		out[i] = ref.String()
+	}
+	return out
+}
+
+func TestFindMasks(t *testing.T) {
+	masks := []output.CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{ID: 101, Address: "abc123", FullAddress: "abc123@mozmail.com", Description: "Shopping"}},
+		{Type: "random", Mask: api.RelayAddress{ID: 102, Address: "news", FullAddress: "news@mozmail.com", Description: "Newsletter"}},
+		{Type: "custom", Mask: api.DomainAddress{ID: 101, Address: "shop", FullAddress: "shop@me.mozmail.com", Description: "Shopping"}},
+		{Type: "custom", Mask: api.DomainAddress{ID: 103, Address: "travel", FullAddress: "travel@me.mozmail.com", Description: "news"}},
+	}
+	tests := []struct {
+		name string
+		arg  string
+		want []string
+	}{
+		{"full address", "abc123@mozmail.com", []string{"random:101"}},
+		{"full address ignoring case", "Shop@Me.Mozmail.com", []string{"custom:101"}},
+		{"address is not a local part", "shop@mozmail.com", []string{}},
+		{"local part", "abc123", []string{"random:101"}},
+		{"local part ignoring case", "SHOP", []string{"custom:101"}},
+		{"local part before description", "news", []string{"random:102"}},
+		{"description", "newsletter", []string{"random:102"}},
+		{"description matching several masks", "shopping", []string{"random:101", "custom:101"}},
+		{"no match", "nothing", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, refStrings(findMasks(masks, tt.arg)))
+		})
+	}
+}
+
+func TestLookupMask_Errors(t *testing.T) {
+	cfg := testConfig(t, newFakeRelay())
+	masks, err := listCombinedMasks(cfg, nil)
+	require.NoError(t, err)
+
+	_, err = lookupMask(cfg, masks, "nothing@mozmail.com")
+	assert.EqualError(t, err, `no mask matches "nothing@mozmail.com"`)
+	assert.Equal(t, exitNotFound, classifyError(err))
+
+	_, err = lookupMask(cfg, masks, "shopping")
+	assert.EqualError(t, err, `"shopping" matches 2 masks; use one of these IDs:
+  random:101  abc123@mozmail.com
+  custom:101  shop@me.mozmail.com`)
+	assert.Equal(t, exitUsage, classifyError(err))
+}
+
+func TestResolveMaskRef(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		arg  string
+		want string
+	}{
+		{"bare ID matching both types", nil, "101", "101"},
+		{"typed ID", nil, "custom:101", "custom:101"},
+		{"address", nil, "news77@mozmail.com", "random:102"},
+		{"description limited by --random", []string{"--random"}, "shopping", "random:101"},
+		{"description limited by --random=false", []string{"--random=false"}, "Shopping", "custom:101"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "get"}
+			cmd.Flags().Bool("random", false, "")
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			ref, err := resolveMaskRef(cmd, testConfig(t, newFakeRelay()), tt.arg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref.String())
+		})
+	}
+}
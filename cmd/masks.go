@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/filter"
+	"github.com/hastefuI/ffrelayctl/fuzzy"
 	"github.com/hastefuI/ffrelayctl/output"
 	"github.com/spf13/cobra"
 )
@@ -71,31 +73,35 @@ var masksListCmd = &cobra.Command{
 	Short: "List all masks",
 	Long: `List all email masks.
 
+` + filterHelp + `
+
 Examples:
   ffrelayctl masks list                # List all masks (both random and custom domain)
   ffrelayctl masks list --random=true  # List only random masks
-  ffrelayctl masks list --random=false # List only custom domain masks`,
+  ffrelayctl masks list --random=false # List only custom domain masks
+  ffrelayctl masks list --filter 'enabled=false and num_spam>0'
+  ffrelayctl masks list --filter 'last_used_at<90d or last_used_at=null'
+  ffrelayctl masks list --filter 'type=custom and description~shop'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
+		expr, err := listFilter(cmd, maskFilterSamples()...)
+		if err != nil {
+			return err
+		}
+		if streamer, lw, ok := lineStreamer(cfg); ok {
+			return streamMasks(streamer, lw, expr)
+		}
+
 		if randomMask == nil {
-			relayAddresses, err := cfg.Client.ListRelayAddresses()
+			combined, err := listCombinedMasks(cfg, randomMask)
 			if err != nil {
 				return err
 			}
-			domainAddresses, err := cfg.Client.ListDomainAddresses()
+			combined, err = filterItems(expr, combined)
 			if err != nil {
 				return err
 			}
-
-			combined := make([]output.CombinedMask, 0)
-			for _, addr := range relayAddresses {
-				combined = append(combined, output.CombinedMask{Type: "random", Mask: addr})
-			}
-			for _, addr := range domainAddresses {
-				combined = append(combined, output.CombinedMask{Type: "custom", Mask: addr})
-			}
-
-			return output.Print(cfg.OutputFormat, combined)
+			return cfg.Printer.Print(combined)
 		}
 
 		if *randomMask {
@@ -103,55 +109,221 @@ Examples:
 			if err != nil {
 				return err
 			}
-			return output.Print(cfg.OutputFormat, addresses)
+			addresses, err = filterItems(expr, addresses)
+			if err != nil {
+				return err
+			}
+			return cfg.Printer.Print(addresses)
 		} else {
 			addresses, err := cfg.Client.ListDomainAddresses()
 			if err != nil {
 				return err
 			}
-			return output.Print(cfg.OutputFormat, addresses)
+			addresses, err = filterItems(expr, addresses)
+			if err != nil {
+				return err
+			}
+			return cfg.Printer.Print(addresses)
 		}
 	},
 }
 
+// maskFilterSamples returns values of the types masks list prints, for
+// checking the fields of a filter.
+func maskFilterSamples() []interface{} {
+	switch {
+	case randomMask == nil:
+		return []interface{}{
+			output.CombinedMask{Type: "random", Mask: api.RelayAddress{}},
+			output.CombinedMask{Type: "custom", Mask: api.DomainAddress{}},
+		}
+	case *randomMask:
+		return []interface{}{api.RelayAddress{}}
+	}
+	return []interface{}{api.DomainAddress{}}
+}
+
+// maskListResults returns samples of what masks list prints for the
+// --random flag of cmd. It is read from the flag because randomMask is set
+// only after the results are checked.
+func maskListResults(cmd *cobra.Command) []interface{} {
+	if !cmd.Flags().Changed("random") {
+		return []interface{}{[]output.CombinedMask{}}
+	}
+	if random, _ := cmd.Flags().GetBool("random"); random {
+		return []interface{}{[]api.RelayAddress{}}
+	}
+	return []interface{}{[]api.DomainAddress{}}
+}
+
+// maskResults returns samples of the mask a command prints: the type chosen
+// with --random, or either type, as a reference such as custom:12345 may
+// choose it.
+func maskResults(cmd *cobra.Command) []interface{} {
+	if !cmd.Flags().Changed("random") {
+		return []interface{}{api.RelayAddress{}, api.DomainAddress{}}
+	}
+	if random, _ := cmd.Flags().GetBool("random"); random {
+		return []interface{}{api.RelayAddress{}}
+	}
+	return []interface{}{api.DomainAddress{}}
+}
+
+func streamMasks(streamer api.Streamer, lw *output.LineWriter, expr *filter.Expr) error {
+	write := func(v interface{}) error {
+		ok, err := matches(expr, v)
+		if err != nil || !ok {
+			return err
+		}
+		return lw.Write(v)
+	}
+
+	if randomMask == nil || *randomMask {
+		err := streamer.StreamRelayAddresses(func(addr api.RelayAddress) error {
+			if randomMask == nil {
+				return write(output.CombinedMask{Type: "random", Mask: addr})
+			}
+			return write(addr)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if randomMask == nil || !*randomMask {
+		return streamer.StreamDomainAddresses(func(addr api.DomainAddress) error {
+			if randomMask == nil {
+				return write(output.CombinedMask{Type: "custom", Mask: addr})
+			}
+			return write(addr)
+		})
+	}
+	return nil
+}
+
+// listCombinedMasks fetches the masks of the selected types, or of both
+// types if random is nil, tagged with their type.
+func listCombinedMasks(cfg *CmdConfig, random *bool) ([]output.CombinedMask, error) {
+	combined := make([]output.CombinedMask, 0)
+	if random == nil || *random {
+		relayAddresses, err := cfg.Client.ListRelayAddresses()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range relayAddresses {
+			combined = append(combined, output.CombinedMask{Type: "random", Mask: addr})
+		}
+	}
+	if random == nil || !*random {
+		domainAddresses, err := cfg.Client.ListDomainAddresses()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range domainAddresses {
+			combined = append(combined, output.CombinedMask{Type: "custom", Mask: addr})
+		}
+	}
+	return combined, nil
+}
+
+var masksSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search masks by address, description and website",
+	Long: `Search masks with a fuzzy match of the query against their address,
+description, and the website they were generated for or are used on.
+
+Characters of each word of the query must appear in order, but not
+necessarily next to each other, so "untd" finds "United Airlines". Every
+word must match. Results are ranked by match quality, with recently used
+masks first among similar matches.
+
+Examples:
+  ffrelayctl masks search airline
+  ffrelayctl masks search "united tickets" --limit 3
+  ffrelayctl masks search shop --random=false --output json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return fmt.Errorf("failed to get limit flag: %w", err)
+		}
+		if limit < 0 {
+			return withExitCode(fmt.Errorf("--limit must not be negative"), exitUsage)
+		}
+
+		masks, err := listCombinedMasks(cfg, randomMask)
+		if err != nil {
+			return err
+		}
+		results := fuzzy.Rank(strings.Join(args, " "), masks, maskCandidate)
+		if limit > 0 && len(results) > limit {
+			results = results[:limit]
+		}
+		return cfg.Printer.Print(results)
+	},
+}
+
+func maskCandidate(m output.CombinedMask) fuzzy.Candidate {
+	var c fuzzy.Candidate
+	var lastUsed *string
+	switch mask := m.Mask.(type) {
+	case api.RelayAddress:
+		c.Fields = []string{mask.FullAddress, mask.Description, mask.GeneratedFor, mask.UsedOn}
+		lastUsed = mask.LastUsedAt
+	case api.DomainAddress:
+		c.Fields = []string{mask.FullAddress, mask.Description}
+		lastUsed = mask.LastUsedAt
+	}
+	if lastUsed != nil {
+		c.LastUsed, _ = time.Parse(time.RFC3339, *lastUsed)
+	}
+	return c
+}
+
 var masksGetCmd = &cobra.Command{
-	Use:   "get <ID>",
+	Use:   "get <MASK>",
 	Short: "Get a specific mask",
-	Long: `Get details of a specific email mask by ID.
+	Long: `Get details of a specific email mask by ID, address or description.
 
-When --random flag is not specified, automatically checks both random and custom domain masks.
+When --random flag is not specified and a numeric ID is given, automatically
+checks both random and custom domain masks.
+
+` + maskRefHelp + `
 
 Examples:
   ffrelayctl masks get 12345                # Try random first, then custom domain if premium
   ffrelayctl masks get 12345 --random=true  # Get random mask only
-  ffrelayctl masks get 12345 --random=false # Get custom domain mask only`,
+  ffrelayctl masks get 12345 --random=false # Get custom domain mask only
+  ffrelayctl masks get custom:12345         # Get custom domain mask only
+  ffrelayctl masks get abc123@mozmail.com   # Get mask by address`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
-		id, err := strconv.Atoi(args[0])
+		ref, err := resolveMaskRef(cmd, cfg, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid ID: %v", err)
+			return err
 		}
+		id := ref.id
 
-		if randomMask != nil {
-			if *randomMask {
+		if ref.random != nil {
+			if *ref.random {
 				address, err := cfg.Client.GetRelayAddress(id)
 				if err != nil {
 					return err
 				}
-				return output.Print(cfg.OutputFormat, address)
+				return cfg.Printer.Print(address)
 			} else {
 				address, err := cfg.Client.GetDomainAddress(id)
 				if err != nil {
 					return err
 				}
-				return output.Print(cfg.OutputFormat, address)
+				return cfg.Printer.Print(address)
 			}
 		}
 
 		address, err := cfg.Client.GetRelayAddress(id)
 		if err == nil {
-			return output.Print(cfg.OutputFormat, address)
+			return cfg.Printer.Print(address)
 		}
 
 		profiles, profileErr := cfg.Client.GetProfiles()
@@ -162,7 +334,7 @@ Examples:
 		if len(profiles) > 0 && profiles[0].HasPremium {
 			domainAddress, domainErr := cfg.Client.GetDomainAddress(id)
 			if domainErr == nil {
-				return output.Print(cfg.OutputFormat, domainAddress)
+				return cfg.Printer.Print(domainAddress)
 			}
 			return err
 		}
@@ -217,7 +389,7 @@ For custom domain masks (--random=false, Premium required):
 			if err != nil {
 				return err
 			}
-			return output.Print(cfg.OutputFormat, address)
+			return cfg.Printer.Print(address)
 		} else {
 			address, err := cmd.Flags().GetString("address")
 			if err != nil {
@@ -238,83 +410,119 @@ For custom domain masks (--random=false, Premium required):
 			if err != nil {
 				return err
 			}
-			return output.Print(cfg.OutputFormat, domainAddress)
+			return cfg.Printer.Print(domainAddress)
 		}
 	},
 }
 
 var masksUpdateCmd = &cobra.Command{
-	Use:   "update <ID>",
+	Use:   "update [<MASK>]",
 	Short: "Update a mask",
-	Long: `Update an existing email mask.
+	Long: `Update an existing email mask, or many masks at once.
+
+` + maskRefHelp + `
+
+` + bulkHelp + `
 
 Examples:
   ffrelayctl masks update 12345 --disabled
   ffrelayctl masks update 12345 --description "New description"
-  ffrelayctl masks update 12345 --random=false --enabled`,
-	Args: cobra.ExactArgs(1),
+  ffrelayctl masks update 12345 --random=false --enabled
+  ffrelayctl masks update custom:12345 --enabled
+  ffrelayctl masks update abc123@mozmail.com --disabled
+  ffrelayctl masks update --filter 'used_on~example.com' --disabled --dry-run
+  ffrelayctl masks update --filter 'description~newsletter' --block-list
+  cut -f1 masks.txt | ffrelayctl masks update --ids - --disabled --force
+  ffrelayctl masks update --resume ~/.cache/ffrelayctl/journals/masks-update-20260101-120000-4242.jsonl`,
+	Args: maskOrSelectorArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			return fmt.Errorf("invalid ID: %v", err)
-		}
-
 		fields, err := parseCommonUpdateFlags(cmd)
 		if err != nil {
 			return err
 		}
-
-		if randomMask == nil || *randomMask {
-			req := api.UpdateRelayAddressRequest{
-				Enabled:         fields.enabled,
-				Description:     fields.description,
-				BlockListEmails: fields.blockListEmails,
-			}
-			if cmd.Flags().Changed("used-on") {
-				usedOn, err := cmd.Flags().GetString("used-on")
-				if err != nil {
-					return fmt.Errorf("failed to get used-on flag: %w", err)
-				}
-				req.UsedOn = &usedOn
+		var usedOn *string
+		if cmd.Flags().Changed("used-on") {
+			val, err := cmd.Flags().GetString("used-on")
+			if err != nil {
+				return fmt.Errorf("failed to get used-on flag: %w", err)
 			}
+			usedOn = &val
+		}
 
-			address, err := cfg.Client.UpdateRelayAddress(id, req)
-			if err != nil {
-				return err
+		if isBulk(cmd) {
+			changed := fields != (commonUpdateFields{}) || usedOn != nil
+			if isResume(cmd) && changed {
+				return withExitCode(fmt.Errorf("changes cannot be given with --resume, the journal records them"), exitUsage)
 			}
-			return output.Print(cfg.OutputFormat, address)
-		} else {
-			req := api.UpdateDomainAddressRequest{
-				Enabled:         fields.enabled,
-				Description:     fields.description,
-				BlockListEmails: fields.blockListEmails,
+			if !isResume(cmd) && !changed {
+				return withExitCode(fmt.Errorf("no changes given: use --enabled, --disabled, --description, --used-on, --block-list or --no-block-list"), exitUsage)
 			}
-
-			address, err := cfg.Client.UpdateDomainAddress(id, req)
-			if err != nil {
+			update := &bulkUpdate{Enabled: fields.enabled, Description: fields.description, BlockListEmails: fields.blockListEmails, UsedOn: usedOn}
+			return runBulk(cmd, cfg, "masks update", "Update", "updated", update, func(t bulkTarget, u *bulkUpdate) error {
+				fields := commonUpdateFields{enabled: u.Enabled, description: u.Description, blockListEmails: u.BlockListEmails}
+				_, err := updateMask(cfg, t.ID, t.Type == "random", fields, u.UsedOn)
 				return err
-			}
-			return output.Print(cfg.OutputFormat, address)
+			})
 		}
+
+		ref, err := resolveMaskRef(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+		address, err := updateMask(cfg, ref.id, ref.isRandom(randomMask == nil || *randomMask), fields, usedOn)
+		if err != nil {
+			return err
+		}
+		return cfg.Printer.Print(address)
 	},
 }
 
+// updateMask applies fields to a mask. usedOn only applies to random masks.
+func updateMask(cfg *CmdConfig, id int, random bool, fields commonUpdateFields, usedOn *string) (interface{}, error) {
+	if random {
+		req := api.UpdateRelayAddressRequest{
+			Enabled:         fields.enabled,
+			Description:     fields.description,
+			BlockListEmails: fields.blockListEmails,
+			UsedOn:          usedOn,
+		}
+		return cfg.Client.UpdateRelayAddress(id, req)
+	}
+
+	req := api.UpdateDomainAddressRequest{
+		Enabled:         fields.enabled,
+		Description:     fields.description,
+		BlockListEmails: fields.blockListEmails,
+	}
+	return cfg.Client.UpdateDomainAddress(id, req)
+}
+
 var masksDeleteCmd = &cobra.Command{
-	Use:   "delete <ID>",
+	Use:   "delete [<MASK>]",
 	Short: "Delete a mask",
-	Long: `Delete an email mask.
+	Long: `Delete an email mask, or many masks at once.
+
+` + maskRefHelp + `
+
+` + bulkHelp + `
 
 Examples:
   ffrelayctl masks delete 12345                      # Delete with confirmation
   ffrelayctl masks delete 12345 --force              # Delete without confirmation
-  ffrelayctl masks delete 12345 --random=false       # Delete custom domain mask`,
-	Args: cobra.ExactArgs(1),
+  ffrelayctl masks delete 12345 --random=false       # Delete custom domain mask
+  ffrelayctl masks delete custom:12345               # Delete custom domain mask
+  ffrelayctl masks delete abc123@mozmail.com         # Delete mask by address
+  ffrelayctl masks delete --filter 'enabled=false and last_used_at<1y' --dry-run
+  ffrelayctl masks delete --ids 101,102,custom:7 --journal cleanup.jsonl
+  ffrelayctl masks delete --resume cleanup.jsonl`,
+	Args: maskOrSelectorArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			return fmt.Errorf("invalid ID: %v", err)
+		if isBulk(cmd) {
+			return runBulk(cmd, cfg, "masks delete", "Delete", "deleted", nil, func(t bulkTarget, _ *bulkUpdate) error {
+				return deleteMask(cfg, t.ID, t.Type == "random")
+			})
 		}
 
 		force, err := cmd.Flags().GetBool("force")
@@ -322,13 +530,24 @@ Examples:
 			return fmt.Errorf("failed to get force flag: %w", err)
 		}
 
+		ref, err := resolveMaskRef(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+		id := ref.id
+		random := ref.isRandom(randomMask == nil || *randomMask)
+
 		maskType := "random mask"
-		if randomMask != nil && !*randomMask {
+		if !random {
 			maskType = "custom domain mask"
 		}
+		target := fmt.Sprintf("%s %d", maskType, id)
+		if ref.address != "" {
+			target += " (" + cfg.Printer.Redact(ref.address) + ")"
+		}
 
 		if !force {
-			fmt.Printf("Are you sure you want to delete %s %d? This cannot be undone. [y/N]: ", maskType, id)
+			fmt.Printf("Are you sure you want to delete %s? This cannot be undone. [y/N]: ", target)
 			reader := bufio.NewReader(os.Stdin)
 			response, err := reader.ReadString('\n')
 			if err != nil {
@@ -341,43 +560,66 @@ Examples:
 			}
 		}
 
-		if randomMask == nil || *randomMask {
-			if err := cfg.Client.DeleteRelayAddress(id); err != nil {
-				return err
-			}
+		if err := deleteMask(cfg, id, random); err != nil {
+			return err
+		}
+		if random {
 			fmt.Printf("Random mask %d deleted successfully.\n", id)
 		} else {
-			if err := cfg.Client.DeleteDomainAddress(id); err != nil {
-				return err
-			}
 			fmt.Printf("Custom domain mask %d deleted successfully.\n", id)
 		}
 		return nil
 	},
 }
 
+func deleteMask(cfg *CmdConfig, id int, random bool) error {
+	if random {
+		return cfg.Client.DeleteRelayAddress(id)
+	}
+	return cfg.Client.DeleteDomainAddress(id)
+}
+
 func init() {
 	rootCmd.AddCommand(masksCmd)
 	masksCmd.AddCommand(masksListCmd)
+	masksCmd.AddCommand(masksSearchCmd)
 	masksCmd.AddCommand(masksGetCmd)
 	masksCmd.AddCommand(masksCreateCmd)
 	masksCmd.AddCommand(masksUpdateCmd)
 	masksCmd.AddCommand(masksDeleteCmd)
+	commandResults[masksListCmd] = maskListResults
+	printsResults(masksSearchCmd, []output.CombinedMask{})
+	commandResults[masksGetCmd] = maskResults
+	commandResults[masksCreateCmd] = maskResults
+	commandResults[masksUpdateCmd] = func(cmd *cobra.Command) []interface{} {
+		if isBulk(cmd) {
+			return bulkResults(cmd)
+		}
+		return maskResults(cmd)
+	}
+	commandResults[masksDeleteCmd] = func(cmd *cobra.Command) []interface{} {
+		if isBulk(cmd) {
+			return bulkResults(cmd)
+		}
+		return nil
+	}
 	masksCmd.PersistentFlags().Bool("random", false, "Filter by mask type: true for random masks, false for custom domain masks")
 
-	defaultPreRunEList := masksListCmd.PreRunE
-	masksListCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
-		if cmd.Flags().Changed("random") {
-			val, err := cmd.Flags().GetBool("random")
-			if err != nil {
-				return fmt.Errorf("failed to get random flag: %w", err)
+	for _, subCmd := range []*cobra.Command{masksListCmd, masksSearchCmd} {
+		defaultPreRunE := subCmd.PreRunE
+		subCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("random") {
+				val, err := cmd.Flags().GetBool("random")
+				if err != nil {
+					return fmt.Errorf("failed to get random flag: %w", err)
+				}
+				randomMask = &val
 			}
-			randomMask = &val
-		}
-		if defaultPreRunEList != nil {
-			return defaultPreRunEList(cmd, args)
+			if defaultPreRunE != nil {
+				return defaultPreRunE(cmd, args)
+			}
+			return nil
 		}
-		return nil
 	}
 
 	defaultPreRunEGet := masksGetCmd.PreRunE
@@ -415,6 +657,8 @@ func init() {
 		}
 	}
 
+	addFilterFlag(masksListCmd)
+	masksSearchCmd.Flags().Int("limit", 10, "Maximum number of results to show (0 for all)")
 	masksCreateCmd.Flags().String("description", "", "Description for the mask")
 	masksCreateCmd.Flags().String("generated-for", "", "Site the mask was generated for (random masks only)")
 	masksCreateCmd.Flags().String("used-on", "", "Site the mask is used on (random masks only)")
@@ -429,5 +673,8 @@ func init() {
 	masksUpdateCmd.Flags().Bool("no-block-list", false, "Don't block promotional emails")
 	masksUpdateCmd.MarkFlagsMutuallyExclusive("enabled", "disabled")
 	masksUpdateCmd.MarkFlagsMutuallyExclusive("block-list", "no-block-list")
+	masksUpdateCmd.Flags().Bool("force", false, "Skip the confirmation prompt in bulk mode")
+	addBulkFlags(masksUpdateCmd, "Update")
 	masksDeleteCmd.Flags().Bool("force", false, "Skip confirmation prompt")
+	addBulkFlags(masksDeleteCmd, "Delete")
 }
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/hastefuI/ffrelayctl/api"
-	"github.com/hastefuI/ffrelayctl/output"
 	"github.com/spf13/cobra"
 )
 
@@ -26,15 +25,35 @@ var phonesListCmd = &cobra.Command{
 Phone masks are a premium feature that provides virtual phone numbers
 that forward calls and texts to your real phone number.
 
+` + filterHelp + `
+
 Examples:
-  ffrelayctl phones list`,
+  ffrelayctl phones list
+  ffrelayctl phones list --filter 'enabled=false or remaining_minutes<10'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := GetConfig(cmd)
+		expr, err := listFilter(cmd, api.RelayNumber{})
+		if err != nil {
+			return err
+		}
+		if streamer, lw, ok := lineStreamer(cfg); ok {
+			return streamer.StreamRelayNumbers(func(n api.RelayNumber) error {
+				if ok, err := matches(expr, n); err != nil || !ok {
+					return err
+				}
+				return lw.Write(n)
+			})
+		}
+
 		numbers, err := cfg.Client.ListRelayNumbers()
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,numbers)
+		numbers, err = filterItems(expr, numbers)
+		if err != nil {
+			return err
+		}
+		return cfg.Printer.Print(numbers)
 	},
 }
 
@@ -79,7 +98,7 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,number)
+		return cfg.Printer.Print(number)
 	},
 }
 
@@ -104,7 +123,7 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,suggestions)
+		return cfg.Printer.Print(suggestions)
 	},
 }
 
@@ -135,7 +154,7 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,numbers)
+		return cfg.Printer.Print(numbers)
 	},
 }
 
@@ -162,7 +181,7 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat,phones)
+		return cfg.Printer.Print(phones)
 	},
 }
 
@@ -192,7 +211,7 @@ Examples:
 
 		for _, phone := range phones {
 			if phone.ID == id {
-				return output.Print(cfg.OutputFormat,phone)
+				return cfg.Printer.Print(phone)
 			}
 		}
 
@@ -222,13 +241,9 @@ Examples:
 		}
 		phone, err := cfg.Client.RegisterRealPhone(req)
 		if err != nil {
-			if apiErr, ok := err.(*api.APIError); ok {
-				fmt.Fprintln(cmd.OutOrStdout(), apiErr.Body)
-				return nil
-			}
 			return err
 		}
-		return output.Print(cfg.OutputFormat,phone)
+		return cfg.Printer.Print(phone)
 	},
 }
 
@@ -258,13 +273,9 @@ Examples:
 		}
 		phone, err := cfg.Client.VerifyRealPhone(id, req)
 		if err != nil {
-			if apiErr, ok := err.(*api.APIError); ok {
-				fmt.Fprintln(cmd.OutOrStdout(), apiErr.Body)
-				return nil
-			}
 			return err
 		}
-		return output.Print(cfg.OutputFormat,phone)
+		return cfg.Printer.Print(phone)
 	},
 }
 
@@ -318,6 +329,7 @@ Examples:
 func init() {
 	rootCmd.AddCommand(phonesCmd)
 	phonesCmd.AddCommand(phonesListCmd)
+	addFilterFlag(phonesListCmd)
 	phonesCmd.AddCommand(phonesUpdateCmd)
 	phonesCmd.AddCommand(phonesDiscoverCmd)
 	phonesCmd.AddCommand(phonesSearchCmd)
@@ -329,6 +341,15 @@ func init() {
 	phonesForwardCmd.AddCommand(phonesForwardVerifyCmd)
 	phonesForwardCmd.AddCommand(phonesForwardDeleteCmd)
 
+	printsResults(phonesListCmd, []api.RelayNumber{})
+	printsResults(phonesUpdateCmd, api.RelayNumber{})
+	printsResults(phonesDiscoverCmd, api.RelayNumberSuggestions{})
+	printsResults(phonesSearchCmd, []api.PhoneNumberOption{})
+	printsResults(phonesForwardListCmd, []api.RealPhone{})
+	printsResults(phonesForwardGetCmd, api.RealPhone{})
+	printsResults(phonesForwardRegisterCmd, api.RealPhone{})
+	printsResults(phonesForwardVerifyCmd, api.RealPhone{})
+
 	phonesUpdateCmd.Flags().Bool("enabled", false, "Enable call/text forwarding")
 	phonesUpdateCmd.Flags().Bool("disabled", false, "Disable call/text forwarding")
 	phonesUpdateCmd.MarkFlagsMutuallyExclusive("enabled", "disabled")
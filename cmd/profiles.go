@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/hastefuI/ffrelayctl/api"
 	"github.com/spf13/cobra"
 )
 
@@ -20,11 +20,12 @@ var profilesListCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat, profiles)
+		return cfg.Printer.Print(profiles)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(profilesCmd)
 	profilesCmd.AddCommand(profilesListCmd)
+	printsResults(profilesListCmd, []api.Profile{})
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const envPassphraseName = envPrefix + "PASSPHRASE"
+
+// readSecret prompts for a value without echoing it when stdin is a
+// terminal, and otherwise reads a single line from stdin.
+func readSecret(cmd *cobra.Command, prompt string) (string, error) {
+	if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(cmd.ErrOrStderr(), prompt)
+		data, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	line, err := stdinReader(cmd).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// stdinReader returns the buffered reader for the input of cmd. Prompts
+// share one reader per execution so that a line buffered by an earlier
+// prompt is not lost.
+func stdinReader(cmd *cobra.Command) *bufio.Reader {
+	cfg := GetConfig(cmd)
+	if cfg.stdin == nil {
+		cfg.stdin = bufio.NewReader(cmd.InOrStdin())
+	}
+	return cfg.stdin
+}
+
+// readPassphrase returns the credential store passphrase from
+// FFRELAYCTL_PASSPHRASE, or prompts for it on an interactive terminal.
+func readPassphrase(cmd *cobra.Command, prompt string) (string, error) {
+	if passphrase := os.Getenv(envPassphraseName); passphrase != "" {
+		return passphrase, nil
+	}
+	if !stdinIsTerminal(cmd) {
+		return "", fmt.Errorf("stored credentials are encrypted.\nRun interactively or set the %s environment variable", envPassphraseName)
+	}
+	return readSecret(cmd, prompt)
+}
+
+func stdinIsTerminal(cmd *cobra.Command) bool {
+	f, ok := cmd.InOrStdin().(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hastefuI/ffrelayctl/config"
+)
+
+const redactKeyFileName = "redact.key"
+
+// loadRedactionKey returns the secret used to derive --redact pseudonyms,
+// creating it on first use. Keeping one key per installation makes
+// pseudonyms stable across runs without letting others reverse them.
+func loadRedactionKey() ([]byte, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, redactKeyFileName)
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("invalid redaction key in %s: delete the file to create a new one", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read redaction key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate redaction key: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Write the key to a temporary file and link it into place, so that
+	// other processes never read a partly written key.
+	f, err := os.CreateTemp(dir, redactKeyFileName+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write redaction key: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(key)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write redaction key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write redaction key: %w", err)
+	}
+	if err := os.Link(f.Name(), path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// Another process created the key first.
+			return loadRedactionKey()
+		}
+		return nil, fmt.Errorf("failed to write redaction key: %w", err)
+	}
+	return key, nil
+}
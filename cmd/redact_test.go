@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRedactionKey(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	key, err := loadRedactionKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	again, err := loadRedactionKey()
+	require.NoError(t, err)
+	assert.Equal(t, key, again, "the key is kept between runs")
+
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), config.DirName)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary files are left behind")
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, redactKeyFileName), []byte("not hex\n"), 0o600))
+	_, err = loadRedactionKey()
+	assert.ErrorContains(t, err, "invalid redaction key")
+}
+
+func TestLoadRedactionKey_Concurrent(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	const n = 16
+	keys := make([][]byte, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys[i], errs[i] = loadRedactionKey()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, keys[0], keys[i])
+	}
+}
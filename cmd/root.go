@@ -1,20 +1,35 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/config"
+	"github.com/hastefuI/ffrelayctl/credentials"
 	"github.com/hastefuI/ffrelayctl/output"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
-	envKeyName = "FFRELAYCTL_KEY"
+	envPrefix  = "FFRELAYCTL_"
+	envKeyName = envPrefix + "KEY"
+
+	modeAnnotation = "ffrelayctl/mode"
+	modeLocal      = "local"
+	modeNoKey      = "no-key"
+
+	defaultCredentialName = "default"
 )
 
 type VersionInfo struct {
@@ -28,10 +43,50 @@ type CmdConfig struct {
 	BaseURL      string
 	Timeout      time.Duration
 	OutputFormat string
-	Client       *api.Client
+	Printer      *output.Printer
+	Client       api.RelayAPI
 	Ctx          context.Context
 	Cancel       context.CancelFunc
 	VersionInfo  VersionInfo
+	ConfigFile   *config.Config
+	ContextName  string
+	Context      *config.Context
+
+	clientFactory ClientFactory
+	envFlags      map[string]bool
+	// started is set once flags and arguments have been validated, so
+	// that earlier errors can be reported as usage errors.
+	started bool
+	// stop is cancelled by the first interrupt while an operation that
+	// called gracefulStop runs.
+	stop     context.Context
+	stopFunc context.CancelFunc
+	graceful atomic.Bool
+	// stdin buffers the command's input for prompts; see stdinReader.
+	stdin *bufio.Reader
+}
+
+// ClientFactory builds the RelayAPI implementation used by commands once the
+// root flags have been resolved.
+type ClientFactory func(cfg *CmdConfig) (api.RelayAPI, error)
+
+type ExecuteOption func(*CmdConfig)
+
+func WithClientFactory(factory ClientFactory) ExecuteOption {
+	return func(c *CmdConfig) {
+		c.clientFactory = factory
+	}
+}
+
+func defaultClientFactory(cfg *CmdConfig) (api.RelayAPI, error) {
+	var opts []api.ClientOption
+	if cfg.BaseURL != "" {
+		opts = append(opts, api.WithBaseURL(cfg.BaseURL))
+	}
+	opts = append(opts, api.WithTimeout(cfg.Timeout))
+	opts = append(opts, api.WithUserAgent("ffrelayctl/"+cfg.VersionInfo.Version))
+	opts = append(opts, api.WithContext(cfg.Ctx))
+	return api.NewClient(cfg.APIKey, opts...), nil
 }
 
 type configKey struct{}
@@ -41,27 +96,105 @@ func GetConfig(cmd *cobra.Command) *CmdConfig {
 }
 
 var rootCmd = &cobra.Command{
-	Use:          "ffrelayctl",
-	Short:        "Firefox Relay CLI",
-	Long:         `ffrelayctl - A CLI for Firefox Relay.`,
-	SilenceUsage: true,
+	Use:   "ffrelayctl",
+	Short: "Firefox Relay CLI",
+	Long: `ffrelayctl - A CLI for Firefox Relay.
+
+With --output json, errors are written to stderr as a JSON object with the
+fields code, exit_code, status (the HTTP status, if any) and message.
+
+` + exitCodesHelp,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if cmd.Name() == "help" || cmd.Name() == "completion" {
+		switch cmd.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			// Argument completion sets up its own client; see completionClient.
+			return nil
+		}
+		if cmd.HasSubCommands() {
+			// Commands that group subcommands only print their help.
 			return nil
 		}
 
 		cfg := GetConfig(cmd)
+		// Cobra checks these only after this hook; check them first so that
+		// they are reported as usage errors.
+		if err := cmd.ValidateRequiredFlags(); err != nil {
+			return err
+		}
+		if err := cmd.ValidateFlagGroups(); err != nil {
+			return err
+		}
+		cfg.started = true
+		// Report errors before the printer is set up in the format given
+		// with --output; environment variables and the context may still
+		// change it below.
+		cfg.OutputFormat, _ = cmd.Flags().GetString("output")
+
+		if err := bindEnvFlags(cmd, cfg); err != nil {
+			return withExitCode(err, exitUsage)
+		}
+
+		mode := commandMode(cmd)
+		if mode != modeLocal {
+			if err := applyContext(cmd, cfg); err != nil {
+				return err
+			}
+		}
 
 		cfg.APIKey, _ = cmd.Flags().GetString("key")
 		cfg.BaseURL, _ = cmd.Flags().GetString("base-url")
 		cfg.Timeout, _ = cmd.Flags().GetDuration("timeout")
 		cfg.OutputFormat, _ = cmd.Flags().GetString("output")
+		query, _ := cmd.Flags().GetString("query")
+		columns, _ := cmd.Flags().GetStringSlice("columns")
+		sortBy, _ := cmd.Flags().GetString("sort-by")
+		noHeaders, _ := cmd.Flags().GetBool("no-headers")
+		color, _ := cmd.Flags().GetString("color")
+		noPager, _ := cmd.Flags().GetBool("no-pager")
+		timezone, _ := cmd.Flags().GetString("timezone")
+		absoluteTimes, _ := cmd.Flags().GetBool("absolute-times")
+		redact, _ := cmd.Flags().GetBool("redact")
+		outputVersion, _ := cmd.Flags().GetString("output-version")
+
+		var redactionKey []byte
+		if redact {
+			key, err := loadRedactionKey()
+			if err != nil {
+				return err
+			}
+			redactionKey = key
+		}
+
+		printer, err := output.NewPrinter(cfg.OutputFormat,
+			output.WithQuery(query),
+			output.WithColumns(columns),
+			output.WithSortBy(sortBy),
+			output.WithNoHeaders(noHeaders),
+			output.WithColor(color),
+			output.WithPager(!noPager),
+			output.WithTimezone(timezone),
+			output.WithAbsoluteTimes(absoluteTimes),
+			output.WithRedaction(redactionKey),
+			output.WithOutputVersion(outputVersion),
+		)
+		if err != nil {
+			return withExitCode(err, exitUsage)
+		}
+		cfg.Printer = printer
+		if results, ok := commandResults[cmd]; ok {
+			if err := printer.Check(results(cmd)...); err != nil {
+				return withExitCode(err, exitUsage)
+			}
+		}
 
-		if !output.IsValidFormat(cfg.OutputFormat) {
-			return fmt.Errorf("invalid output format %q: must be one of [text|json]", cfg.OutputFormat)
+		if mode == modeLocal {
+			return nil
 		}
 
 		cfg.Ctx, cfg.Cancel = context.WithCancel(cmd.Context())
+		cfg.stop, cfg.stopFunc = context.WithCancel(cfg.Ctx)
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -70,48 +203,350 @@ var rootCmd = &cobra.Command{
 				signal.Stop(sigChan)
 				close(sigChan)
 			}()
-			select {
-			case sig := <-sigChan:
-				fmt.Fprintf(os.Stderr, "\nReceived signal %v, cancelling request...\n", sig)
-				cfg.Cancel()
-			case <-cfg.Ctx.Done():
-				return
+			for {
+				select {
+				case sig := <-sigChan:
+					if cfg.graceful.Load() && cfg.stop.Err() == nil {
+						fmt.Fprintf(os.Stderr, "\nReceived signal %v, finishing requests in progress (interrupt again to cancel them)...\n", sig)
+						cfg.stopFunc()
+						continue
+					}
+					fmt.Fprintf(os.Stderr, "\nReceived signal %v, cancelling request...\n", sig)
+					cfg.Cancel()
+					return
+				case <-cfg.Ctx.Done():
+					return
+				}
 			}
 		}()
 
-		if cfg.APIKey == "" {
-			cfg.APIKey = os.Getenv(envKeyName)
+		if mode == modeNoKey {
+			return nil
+		}
+		return configureClient(cmd, cfg)
+	},
+}
+
+// gracefulStop makes the first interrupt stop the running operation
+// between items instead of cancelling requests in flight, so that their
+// results can be recorded. The operation must start no new items once the
+// returned context is done.
+func (cfg *CmdConfig) gracefulStop() context.Context {
+	cfg.graceful.Store(true)
+	return cfg.stop
+}
+
+// configureClient resolves the API key and creates cfg.Client.
+func configureClient(cmd *cobra.Command, cfg *CmdConfig) error {
+	key, _, err := resolveAPIKey(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return withExitCode(fmt.Errorf("no API key provided.\nUse --key <API_KEY>, set the %s environment variable, configure a context with 'ffrelayctl config set key', or run 'ffrelayctl auth login'", envKeyName), exitUnauthorized)
+	}
+	cfg.APIKey = key
+
+	client, err := cfg.clientFactory(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.Client = client
+	return nil
+}
+
+// requireSubcommand makes cmd and its descendants that only group
+// subcommands print their help when run alone, and report unknown
+// subcommands as usage errors. Without it, cobra prints the help and exits
+// successfully.
+func requireSubcommand(cmd *cobra.Command) {
+	if cmd.HasParent() && cmd.HasSubCommands() && !cmd.Runnable() {
+		if cmd.SuggestionsMinimumDistance <= 0 {
+			// The distance cobra uses for unknown top-level commands.
+			cmd.SuggestionsMinimumDistance = 2
+		}
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return nil
+			}
+			msg := fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath())
+			if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+				msg += "\n\nDid you mean this?\n\t" + strings.Join(suggestions, "\n\t")
+			}
+			return errors.New(msg)
+		}
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
 		}
+	}
+	for _, sub := range cmd.Commands() {
+		requireSubcommand(sub)
+	}
+}
 
-		if cfg.APIKey == "" {
-			return fmt.Errorf("no API key provided.\nUse --key <API_KEY> or set the %s environment variable", envKeyName)
+// commandMode returns the mode annotation of cmd or its nearest annotated
+// parent. Commands in modeLocal only manage local files; commands in
+// modeNoKey talk to the API but resolve the key themselves.
+func commandMode(cmd *cobra.Command) string {
+	for c := cmd; c != nil; c = c.Parent() {
+		if mode, ok := c.Annotations[modeAnnotation]; ok {
+			return mode
 		}
+	}
+	return ""
+}
+
+// commandResults maps commands to samples of the values they print, so
+// that --columns and --sort-by are checked before any request is made. The
+// samples may depend on the command's flags.
+var commandResults = map[*cobra.Command]func(cmd *cobra.Command) []interface{}{}
+
+// printsResults declares that cmd prints values like samples.
+func printsResults(cmd *cobra.Command, samples ...interface{}) {
+	commandResults[cmd] = func(*cobra.Command) []interface{} {
+		return samples
+	}
+}
+
+// resolveAPIKey returns the API key and a description of where it was found,
+// checking in order: --key, --key-cmd, --key-file, FFRELAYCTL_KEY, the
+// selected context, and the encrypted credential store. It returns an empty
+// key if none is configured.
+func resolveAPIKey(cmd *cobra.Command, cfg *CmdConfig) (string, string, error) {
+	if cfg.APIKey != "" && !cfg.envFlags["key"] {
+		return cfg.APIKey, "--key", nil
+	}
 
-		var opts []api.ClientOption
-		if cfg.BaseURL != "" {
-			opts = append(opts, api.WithBaseURL(cfg.BaseURL))
+	if command, _ := cmd.Flags().GetString("key-cmd"); command != "" {
+		key, err := credentials.FromCommand(cfg.Ctx, command, credentials.DefaultCommandTimeout)
+		if err != nil {
+			return "", "", err
+		}
+		return key, flagSource(cfg, "key-cmd"), nil
+	}
+	if path, _ := cmd.Flags().GetString("key-file"); path != "" {
+		key, err := credentials.FromFile(path)
+		if err != nil {
+			return "", "", err
 		}
-		opts = append(opts, api.WithTimeout(cfg.Timeout))
-		opts = append(opts, api.WithUserAgent("ffrelayctl/"+cfg.VersionInfo.Version))
-		opts = append(opts, api.WithContext(cfg.Ctx))
-		cfg.Client = api.NewClient(cfg.APIKey, opts...)
+		return key, flagSource(cfg, "key-file"), nil
+	}
+
+	if cfg.APIKey != "" {
+		return cfg.APIKey, flagSource(cfg, "key"), nil
+	}
 
+	if cfg.Context != nil {
+		key, err := resolveContextKey(cfg)
+		if err != nil {
+			return "", "", fmt.Errorf("context %q: %w", cfg.ContextName, err)
+		}
+		if key != "" {
+			return key, fmt.Sprintf("context %q (%s)", cfg.ContextName, cfg.Context.KeySource()), nil
+		}
+	}
+
+	key, err := loadStoredKey(cmd, cfg)
+	if err != nil || key == "" {
+		return "", "", err
+	}
+	return key, "credential store", nil
+}
+
+func resolveContextKey(cfg *CmdConfig) (string, error) {
+	if key := cfg.Context.ResolveKey(); key != "" {
+		return key, nil
+	}
+	if cfg.Context.KeyCmd != "" {
+		return credentials.FromCommand(cfg.Ctx, cfg.Context.KeyCmd, credentials.DefaultCommandTimeout)
+	}
+	if cfg.Context.KeyFile != "" {
+		return credentials.FromFile(cfg.Context.KeyFile)
+	}
+	return "", nil
+}
+
+func flagSource(cfg *CmdConfig, name string) string {
+	if cfg.envFlags[name] {
+		return "env:" + envName(name)
+	}
+	return "--" + name
+}
+
+func loadStoredKey(cmd *cobra.Command, cfg *CmdConfig) (string, error) {
+	store, err := credentialStore()
+	if err != nil {
+		return "", err
+	}
+	name := credentialName(cfg)
+	ok, err := store.Has(name)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	passphrase, err := readPassphrase(cmd, fmt.Sprintf("Passphrase for stored credentials (%s): ", name))
+	if err != nil {
+		return "", err
+	}
+	key, err := store.Load(name, passphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to unlock stored credentials: %w", err)
+	}
+	return key, nil
+}
+
+func credentialStore() (*credentials.Store, error) {
+	path, err := credentials.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewStore(path), nil
+}
+
+// credentialName is the credential store entry for the selected context.
+func credentialName(cfg *CmdConfig) string {
+	if cfg.ContextName != "" {
+		return cfg.ContextName
+	}
+	return defaultCredentialName
+}
+
+// bindEnvFlags sets every root flag not given on the command line from its
+// FFRELAYCTL_* environment variable, e.g. --base-url from FFRELAYCTL_BASE_URL.
+func bindEnvFlags(cmd *cobra.Command, cfg *CmdConfig) error {
+	var bindErr error
+	cfg.envFlags = make(map[string]bool)
+	cmd.Root().PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if bindErr != nil || cmd.Flags().Changed(f.Name) {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || value == "" {
+			return
+		}
+		if err := cmd.Flags().Set(f.Name, value); err != nil {
+			bindErr = fmt.Errorf("invalid value for %s: %w", envName(f.Name), err)
+			return
+		}
+		cfg.envFlags[f.Name] = true
+	})
+	return bindErr
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func loadConfigFile(cmd *cobra.Command) (*config.Config, error) {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	file, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	// Like a key file, a config file holding a key must be private.
+	if file.HasInlineKey() {
+		if err := credentials.CheckPermissions(path); err != nil {
+			return nil, fmt.Errorf("config file stores an API key: %w", err)
+		}
+	}
+	return file, nil
+}
+
+// selectedContextName returns the context chosen with --context, or the
+// config file's current context.
+func selectedContextName(cmd *cobra.Command, file *config.Config) string {
+	if name, _ := cmd.Flags().GetString("context"); name != "" {
+		return name
+	}
+	return file.CurrentContext
+}
+
+// applyContext fills in root flags that were not set on the command line or
+// through the environment from the selected config file context.
+func applyContext(cmd *cobra.Command, cfg *CmdConfig) error {
+	file, err := loadConfigFile(cmd)
+	if err != nil {
+		return err
+	}
+	cfg.ConfigFile = file
+
+	name := selectedContextName(cmd, file)
+	if name == "" {
 		return nil
-	},
+	}
+	ctx, err := file.Context(name)
+	if err != nil {
+		if cmd.Flags().Changed("context") {
+			// An unknown --context is an invalid argument, unlike an
+			// unknown current context in the config file.
+			return withExitCode(err, exitUsage)
+		}
+		return err
+	}
+	cfg.ContextName = name
+	cfg.Context = ctx
+
+	settings := map[string]string{
+		"base-url": ctx.BaseURL,
+		"output":   ctx.Output,
+		"timeout":  ctx.Timeout,
+	}
+	for flagName, value := range settings {
+		if value == "" || cmd.Flags().Changed(flagName) {
+			continue
+		}
+		if err := cmd.Flags().Set(flagName, value); err != nil {
+			return fmt.Errorf("invalid %s in context %q: %w", flagName, name, err)
+		}
+	}
+	return nil
 }
 
 func init() {
 	rootCmd.PersistentFlags().String("base-url", "", fmt.Sprintf("Base URL for the API (default: %s)", api.DefaultBaseURL))
 	rootCmd.PersistentFlags().String("key", "", "API key for authentication")
-	rootCmd.PersistentFlags().StringP("output", "o", output.FormatText, "Output format [text|json]")
+	rootCmd.PersistentFlags().String("key-cmd", "", "Command that prints the API key (e.g., \"pass show firefox-relay\")")
+	rootCmd.PersistentFlags().String("key-file", "", "File containing the API key (must be readable only by you)")
+	rootCmd.PersistentFlags().StringP("output", "o", output.FormatText, "Output format ["+output.FormatList()+"]")
+	rootCmd.PersistentFlags().String("output-version", output.LatestOutputVersion, "Version of the JSON output shape; "+output.LatestOutputVersion+" is currently the only accepted value (see 'ffrelayctl schema')")
+	rootCmd.PersistentFlags().StringP("query", "q", "", "jq expression to apply to the JSON output before printing")
+	rootCmd.PersistentFlags().StringSlice("columns", nil, "Comma-separated fields to show in tables (e.g., id,full_address,created_at)")
+	rootCmd.PersistentFlags().String("sort-by", "", "Field to sort lists by; prefix with \"-\" for descending order (e.g., -num_forwarded)")
+	rootCmd.PersistentFlags().Bool("no-headers", false, "Omit the header row from tables")
+	rootCmd.PersistentFlags().String("color", output.ColorAuto, "Colorize text output ["+strings.Join(output.ColorModes(), "|")+"]; NO_COLOR disables auto")
+	rootCmd.PersistentFlags().Bool("no-pager", false, "Do not page long text output through $PAGER")
+	rootCmd.PersistentFlags().String("timezone", "", "Time zone for absolute times, e.g. UTC or Europe/Berlin (default: local)")
+	rootCmd.PersistentFlags().Bool("absolute-times", false, "Show dates and times instead of relative times (e.g., \"3 days ago\") in text output")
+	rootCmd.PersistentFlags().Bool("redact", false, "Replace email addresses, phone numbers and the subdomain with stable pseudonyms in output")
 	rootCmd.PersistentFlags().Duration("timeout", api.DefaultTimeout, "HTTP request timeout (e.g., 15s, 2m)")
+	rootCmd.PersistentFlags().String("context", "", "Config context to use (default: current context)")
+	rootCmd.PersistentFlags().String("config", "", "Path to the config file (default: $XDG_CONFIG_HOME/ffrelayctl/config.yaml)")
 }
 
-func Execute(vi VersionInfo) {
+func Execute(vi VersionInfo, opts ...ExecuteOption) {
+	if status := execute(vi, os.Args[1:], os.Stderr, opts...); status != 0 {
+		os.Exit(status)
+	}
+}
+
+// execute runs the command line args and returns the exit status. Errors
+// are reported to stderr.
+func execute(vi VersionInfo, args []string, stderr io.Writer, opts ...ExecuteOption) int {
 	cfg := &CmdConfig{
-		Timeout:      api.DefaultTimeout,
-		OutputFormat: output.FormatText,
-		VersionInfo:  vi,
+		Timeout:       api.DefaultTimeout,
+		OutputFormat:  output.FormatText,
+		VersionInfo:   vi,
+		clientFactory: defaultClientFactory,
+	}
+	for _, opt := range opts {
+		opt(cfg)
 	}
 
 	ctxWithConfig := context.WithValue(context.Background(), configKey{}, cfg)
@@ -120,10 +555,20 @@ func Execute(vi VersionInfo) {
 	rootCmd.Version = vi.Version
 	rootCmd.SetVersionTemplate(fmt.Sprintf("ffrelayctl version %s\ncommit: %s\nbuilt at: %s\n", vi.Version, vi.Commit, vi.Date))
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
-	}
+	requireSubcommand(rootCmd)
+	rootCmd.SetArgs(args)
+	executed, err := rootCmd.ExecuteC()
 	if cfg.Cancel != nil {
 		cfg.Cancel()
 	}
+	if err != nil {
+		format := cfg.OutputFormat
+		if !cfg.started {
+			// Cobra validates flags and arguments before running any hooks.
+			err = withExitCode(err, exitUsage)
+			format, _ = rootCmd.PersistentFlags().GetString("output")
+		}
+		return reportError(stderr, format, executed, err)
+	}
+	return 0
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cli runs command lines against a fake client through WithClientFactory,
+// with config and cache directories private to the test.
+type cli struct {
+	t      *testing.T
+	client api.RelayAPI
+}
+
+func newCLI(t *testing.T, client api.RelayAPI) *cli {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	for _, env := range os.Environ() {
+		if name, _, _ := strings.Cut(env, "="); strings.HasPrefix(name, envPrefix) {
+			t.Setenv(name, "")
+		}
+	}
+	return &cli{t: t, client: client}
+}
+
+// run executes the command line args and returns stdout, stderr and the
+// exit status.
+func (c *cli) run(args ...string) (string, string, int) {
+	c.t.Helper()
+	return c.runWithInput("", args...)
+}
+
+// runWithInput is like run with stdin reading from input.
+func (c *cli) runWithInput(input string, args ...string) (string, string, int) {
+	t := c.t
+	t.Helper()
+	defer resetCommand(rootCmd)
+	// The masks commands keep --random in a package variable.
+	defer func() { randomMask = nil }()
+
+	// Printer.Print writes to os.Stdout rather than the command's writer.
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	defer stdout.Close()
+	realStdout := os.Stdout
+	os.Stdout = stdout
+	defer func() { os.Stdout = realStdout }()
+	rootCmd.SetOut(stdout)
+	rootCmd.SetIn(strings.NewReader(input))
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetIn(nil)
+
+	factory := func(cfg *CmdConfig) (api.RelayAPI, error) {
+		return c.client, nil
+	}
+	var stderr bytes.Buffer
+	rootCmd.SetErr(&stderr)
+	defer rootCmd.SetErr(nil)
+	status := execute(VersionInfo{Version: "test"}, args, &stderr, WithClientFactory(factory))
+
+	out, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	return string(out), stderr.String(), status
+}
+
+// resetCommand restores the flags of cmd and its subcommands to their
+// defaults and clears the contexts cobra passed down, since the command
+// tree is shared between runs.
+func resetCommand(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	// With no context, cobra passes the root command's down again.
+	cmd.SetContext(nil)
+	for _, sub := range cmd.Commands() {
+		resetCommand(sub)
+	}
+}
+
+func TestExecute_ClientFactory(t *testing.T) {
+	stdout, stderr, status := newCLI(t, newFakeRelay()).run("masks", "list", "--key", "test-key", "-o", "json", "--query", "[.[] | .type + \":\" + .mask.full_address]")
+	require.Equal(t, 0, status, stderr)
+	assert.JSONEq(t, `["random:abc123@mozmail.com", "random:news77@mozmail.com", "custom:shop@me.mozmail.com"]`, stdout)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [NAME]",
+	Short: "Show the JSON Schemas of the JSON output",
+	Long: `Show the JSON Schemas describing the JSON output of each command.
+
+Without arguments, the available schemas are listed. With a name, the schema
+is printed as a JSON Schema (draft 2020-12) document. List commands print
+arrays of the listed objects.
+
+The shape of JSON output is versioned. Tools that parse it should pin a
+version with --output-version (or FFRELAYCTL_OUTPUT_VERSION), which keeps
+the output in the shape of that version's schemas after upgrades. Version 1
+is currently the only accepted value. The export document records its
+version in the schema_version field.
+
+Examples:
+  ffrelayctl schema
+  ffrelayctl schema mask
+  ffrelayctl schema export --output-version 1 > export.schema.json`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{modeAnnotation: modeLocal},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := GetConfig(cmd)
+		version := cfg.Printer.OutputVersion()
+
+		if len(args) == 0 {
+			schemas, err := output.Schemas(version)
+			if err != nil {
+				return err
+			}
+			return cfg.Printer.Print(schemas)
+		}
+
+		data, err := output.Schema(version, args[0])
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOutputVersion checks that --output-version selects the schemas that
+// are printed and is stamped into exports as the version those schemas
+// require.
+func TestOutputVersion(t *testing.T) {
+	for _, version := range output.OutputVersions() {
+		t.Run(version, func(t *testing.T) {
+			c := newCLI(t, newFakeRelay())
+			want, err := output.Schema(version, "export")
+			require.NoError(t, err)
+
+			stdout, stderr, status := c.run("schema", "export", "--output-version", version)
+			require.Equal(t, 0, status, stderr)
+			assert.Equal(t, string(want), stdout)
+
+			var schema struct {
+				Properties struct {
+					SchemaVersion struct {
+						Const string `json:"const"`
+					} `json:"schema_version"`
+				} `json:"properties"`
+			}
+			require.NoError(t, json.Unmarshal(want, &schema))
+			assert.Equal(t, version, schema.Properties.SchemaVersion.Const)
+
+			stdout, stderr, status = c.run("export", "-o", "json", "--key", "test-key", "--output-version", version)
+			require.Equal(t, 0, status, stderr)
+			var export output.AccountExport
+			require.NoError(t, json.Unmarshal([]byte(stdout), &export))
+			assert.Equal(t, version, export.SchemaVersion)
+			assert.Empty(t, validateSchema(t, version, "export", stdout))
+		})
+	}
+}
+
+// validateSchema checks a JSON document against a schema of an output
+// version and returns the violations. It covers the keywords the schemas
+// use; format is an annotation in draft 2020-12 and is not checked.
+func validateSchema(t *testing.T, version, name, doc string) []string {
+	t.Helper()
+	schema := loadSchema(t, version, name)
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	var value interface{}
+	require.NoError(t, dec.Decode(&value))
+
+	v := &schemaValidator{t: t, version: version}
+	v.validate(schema, value, "$")
+	return v.errors
+}
+
+func loadSchema(t *testing.T, version, name string) interface{} {
+	t.Helper()
+	data, err := output.Schema(version, name)
+	require.NoError(t, err)
+	var schema interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	return schema
+}
+
+type schemaValidator struct {
+	t       *testing.T
+	version string
+	errors  []string
+}
+
+func (v *schemaValidator) fail(at, format string, args ...interface{}) {
+	v.errors = append(v.errors, at+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *schemaValidator) validate(schema, value interface{}, at string) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		if schema == false {
+			v.fail(at, "not allowed")
+		}
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		v.validate(loadSchema(v.t, v.version, strings.TrimSuffix(ref, ".json")), value, at)
+	}
+	if typ, ok := s["type"]; ok && !hasSchemaType(typ, value) {
+		v.fail(at, "%v is not of type %v", value, typ)
+		return
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, plainJSON(value)) {
+		v.fail(at, "%v is not %v", value, c)
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, plainJSON(value))
+		}
+		if !found {
+			v.fail(at, "%v is not one of %v", value, enum)
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			branch := &schemaValidator{t: v.t, version: v.version}
+			branch.validate(sub, value, at)
+			if len(branch.errors) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(at, "matches %d of the oneOf schemas", matches)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		for _, name := range asStrings(s["required"]) {
+			if _, ok := value[name]; !ok {
+				v.fail(at, "missing required property %q", name)
+			}
+		}
+		for name, field := range value {
+			if prop, ok := props[name]; ok {
+				v.validate(prop, field, at+"."+name)
+			} else if extra, ok := s["additionalProperties"]; ok {
+				v.validate(extra, field, at+"."+name)
+			}
+		}
+	case []interface{}:
+		if min, ok := s["minItems"].(float64); ok && len(value) < int(min) {
+			v.fail(at, "has %d items, want at least %v", len(value), min)
+		}
+		prefix, _ := s["prefixItems"].([]interface{})
+		for i, item := range value {
+			itemAt := fmt.Sprintf("%s[%d]", at, i)
+			if i < len(prefix) {
+				v.validate(prefix[i], item, itemAt)
+			} else if items, ok := s["items"]; ok {
+				v.validate(items, item, itemAt)
+			}
+		}
+	}
+}
+
+func hasSchemaType(typ, value interface{}) bool {
+	if types, ok := typ.([]interface{}); ok {
+		for _, t := range types {
+			if hasSchemaType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	switch value := value.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case string:
+		return typ == "string"
+	case json.Number:
+		if typ == "integer" {
+			_, err := value.Int64()
+			return err == nil
+		}
+		return typ == "number"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
+
+// plainJSON converts a json.Number to the float64 that schema keywords
+// decode to, so the two compare equal.
+func plainJSON(value interface{}) interface{} {
+	if n, ok := value.(json.Number); ok {
+		f, _ := n.Float64()
+		return f
+	}
+	return value
+}
+
+func asStrings(value interface{}) []string {
+	list, _ := value.([]interface{})
+	strs := make([]string, 0, len(list))
+	for _, s := range list {
+		strs = append(strs, s.(string))
+	}
+	return strs
+}
+
+func TestValidateSchema(t *testing.T) {
+	valid := `{"schema_version": "1", "masks": [], "phones": [], "profiles": [], "contacts": [], "users": []}`
+	assert.Empty(t, validateSchema(t, "1", "export", valid))
+
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"missing property", `{"schema_version": "1", "masks": [], "phones": [], "profiles": [], "contacts": []}`, `$: missing required property "users"`},
+		{"extra property", strings.Replace(valid, "{", `{"extra": 1, `, 1), "$.extra: not allowed"},
+		{"wrong version", strings.Replace(valid, `"1"`, `"2"`, 1), "$.schema_version: 2 is not 1"},
+		{"wrong type", strings.Replace(valid, `"users": []`, `"users": {}`, 1), "$.users: map[] is not of type array"},
+		{"invalid item", strings.Replace(valid, `"users": []`, `"users": [{"email": 1}]`, 1), "$.users[0].email: 1 is not of type string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, validateSchema(t, "1", "export", tt.doc), tt.want)
+		})
+	}
+}
+
+func TestOutputVersion_Unsupported(t *testing.T) {
+	_, stderr, status := newCLI(t, newFakeRelay()).run("schema", "--output-version", "0")
+	assert.Equal(t, exitUsage.status, status)
+	assert.Contains(t, stderr, `unsupported output version "0"`)
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/hastefuI/ffrelayctl/output"
+)
+
+// lineStreamer returns the client's Streamer and a writer for its items when
+// the output format is JSON Lines, so list commands can print each item as
+// soon as it is decoded.
+func lineStreamer(cfg *CmdConfig) (api.Streamer, *output.LineWriter, bool) {
+	lw, ok := cfg.Printer.LineWriter(os.Stdout)
+	if !ok {
+		return nil, nil, false
+	}
+	streamer, ok := cfg.Client.(api.Streamer)
+	if !ok {
+		return nil, nil, false
+	}
+	return streamer, lw, true
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"github.com/hastefuI/ffrelayctl/output"
+	"github.com/hastefuI/ffrelayctl/api"
 	"github.com/spf13/cobra"
 )
 
@@ -24,11 +24,12 @@ Examples:
 		if err != nil {
 			return err
 		}
-		return output.Print(cfg.OutputFormat, users)
+		return cfg.Printer.Print(users)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(usersCmd)
 	usersCmd.AddCommand(usersListCmd)
+	printsResults(usersListCmd, []api.User{})
 }
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DirName  = "ffrelayctl"
+	FileName = "config.yaml"
+)
+
+const (
+	KeyKey     = "key"
+	KeyKeyEnv  = "key-env"
+	KeyKeyCmd  = "key-cmd"
+	KeyKeyFile = "key-file"
+	KeyBaseURL = "base-url"
+	KeyOutput  = "output"
+	KeyTimeout = "timeout"
+)
+
+// Context holds the settings for one named Relay account or environment.
+type Context struct {
+	Key     string `yaml:"key,omitempty"`
+	KeyEnv  string `yaml:"key-env,omitempty"`
+	KeyCmd  string `yaml:"key-cmd,omitempty"`
+	KeyFile string `yaml:"key-file,omitempty"`
+	BaseURL string `yaml:"base-url,omitempty"`
+	Output  string `yaml:"output,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+type Config struct {
+	CurrentContext string              `yaml:"current-context,omitempty"`
+	Contexts       map[string]*Context `yaml:"contexts,omitempty"`
+
+	path string
+}
+
+func Keys() []string {
+	return []string{KeyKey, KeyKeyEnv, KeyKeyCmd, KeyKeyFile, KeyBaseURL, KeyOutput, KeyTimeout}
+}
+
+// Dir returns the ffrelayctl directory under $XDG_CONFIG_HOME, falling back
+// to the platform user config directory.
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, DirName), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine config directory: %w", err)
+	}
+	return filepath.Join(dir, DirName), nil
+}
+
+// CacheDir returns the ffrelayctl directory under $XDG_CACHE_HOME, falling
+// back to the platform user cache directory.
+func CacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, DirName), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, DirName), nil
+}
+
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the config file at path. A missing file yields an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{
+		Contexts: make(map[string]*Context),
+		path:     path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]*Context)
+	}
+	for name, ctx := range cfg.Contexts {
+		if ctx == nil {
+			cfg.Contexts[name] = &Context{}
+		}
+	}
+
+	return cfg, nil
+}
+
+// HasInlineKey reports whether any context stores an API key in the file
+// itself.
+func (c *Config) HasInlineKey() bool {
+	for _, ctx := range c.Contexts {
+		if ctx.Key != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) Path() string {
+	return c.path
+}
+
+func (c *Config) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("config file path is not set")
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	data := buf.Bytes()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) ContextNames() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) Context(name string) (*Context, error) {
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in %s", name, c.path)
+	}
+	return ctx, nil
+}
+
+func (c *Config) UseContext(name string) error {
+	if _, err := c.Context(name); err != nil {
+		return err
+	}
+	c.CurrentContext = name
+	return nil
+}
+
+func (c *Config) DeleteContext(name string) error {
+	if _, err := c.Context(name); err != nil {
+		return err
+	}
+	delete(c.Contexts, name)
+	if c.CurrentContext == name {
+		c.CurrentContext = ""
+	}
+	return nil
+}
+
+// Set assigns a setting on the named context, creating the context if it
+// does not exist yet. The first context created becomes the current one.
+func (c *Config) Set(name, key, value string) error {
+	if name == "" {
+		return fmt.Errorf("context name must not be empty")
+	}
+
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		ctx = &Context{}
+	}
+
+	if err := ctx.Set(key, value); err != nil {
+		return err
+	}
+
+	c.Contexts[name] = ctx
+	if c.CurrentContext == "" {
+		c.CurrentContext = name
+	}
+	return nil
+}
+
+func (ctx *Context) Set(key, value string) error {
+	switch key {
+	case KeyKey:
+		ctx.Key = value
+	case KeyKeyEnv:
+		ctx.KeyEnv = value
+	case KeyKeyCmd:
+		ctx.KeyCmd = value
+	case KeyKeyFile:
+		ctx.KeyFile = value
+	case KeyBaseURL:
+		ctx.BaseURL = value
+	case KeyOutput:
+		ctx.Output = value
+	case KeyTimeout:
+		if value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid timeout %q: %w", value, err)
+			}
+		}
+		ctx.Timeout = value
+	default:
+		return fmt.Errorf("unknown config key %q: must be one of %v", key, Keys())
+	}
+	return nil
+}
+
+// ResolveKey returns the API key set inline or through key-env. Keys from
+// key-cmd and key-file are resolved by the caller.
+func (ctx *Context) ResolveKey() string {
+	if ctx.Key != "" {
+		return ctx.Key
+	}
+	if ctx.KeyEnv != "" {
+		return os.Getenv(ctx.KeyEnv)
+	}
+	return ""
+}
+
+// KeySource describes where the context's API key comes from without
+// revealing it.
+func (ctx *Context) KeySource() string {
+	switch {
+	case ctx.Key != "":
+		return "config"
+	case ctx.KeyEnv != "":
+		return "env:" + ctx.KeyEnv
+	case ctx.KeyCmd != "":
+		return "key-cmd"
+	case ctx.KeyFile != "":
+		return "key-file:" + ctx.KeyFile
+	default:
+		return "-"
+	}
+}
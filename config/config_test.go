@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg, err := Load(path)
+
+	require.NoError(t, err)
+	assert.Empty(t, cfg.CurrentContext)
+	assert.Empty(t, cfg.Contexts)
+	assert.Equal(t, path, cfg.Path())
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("contexts: [unclosed"), 0o600))
+
+	_, err := Load(path)
+
+	assert.Error(t, err)
+}
+
+func TestConfig_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set("personal", KeyKeyEnv, "RELAY_KEY"))
+	require.NoError(t, cfg.Set("staging", KeyBaseURL, "https://relay.allizom.org"))
+	require.NoError(t, cfg.Set("staging", KeyTimeout, "1m"))
+	require.NoError(t, cfg.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "personal", loaded.CurrentContext)
+	assert.Equal(t, []string{"personal", "staging"}, loaded.ContextNames())
+	assert.Equal(t, "RELAY_KEY", loaded.Contexts["personal"].KeyEnv)
+	assert.Equal(t, "https://relay.allizom.org", loaded.Contexts["staging"].BaseURL)
+	assert.Equal(t, "1m", loaded.Contexts["staging"].Timeout)
+	assert.False(t, loaded.HasInlineKey())
+
+	require.NoError(t, loaded.Set("staging", KeyKey, "secret"))
+	assert.True(t, loaded.HasInlineKey())
+}
+
+func TestConfig_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{name: "key", key: KeyKey, value: "secret"},
+		{name: "output", key: KeyOutput, value: "json"},
+		{name: "valid timeout", key: KeyTimeout, value: "15s"},
+		{name: "invalid timeout", key: KeyTimeout, value: "soon", wantErr: true},
+		{name: "unknown key", key: "colour", value: "blue", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Contexts: make(map[string]*Context)}
+			err := cfg.Set("default", tt.key, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.NotContains(t, cfg.Contexts, "default")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "default", cfg.CurrentContext)
+		})
+	}
+}
+
+func TestConfig_UseAndDeleteContext(t *testing.T) {
+	cfg := &Config{Contexts: map[string]*Context{"a": {}, "b": {}}}
+
+	assert.Error(t, cfg.UseContext("missing"))
+	assert.NoError(t, cfg.UseContext("b"))
+	assert.Equal(t, "b", cfg.CurrentContext)
+
+	assert.NoError(t, cfg.DeleteContext("b"))
+	assert.Empty(t, cfg.CurrentContext)
+	assert.Equal(t, []string{"a"}, cfg.ContextNames())
+	assert.Error(t, cfg.DeleteContext("b"))
+}
+
+func TestContext_ResolveKey(t *testing.T) {
+	t.Setenv("FFRELAYCTL_TEST_KEY", "from-env")
+
+	assert.Equal(t, "inline", (&Context{Key: "inline", KeyEnv: "FFRELAYCTL_TEST_KEY"}).ResolveKey())
+	assert.Equal(t, "from-env", (&Context{KeyEnv: "FFRELAYCTL_TEST_KEY"}).ResolveKey())
+	assert.Equal(t, "", (&Context{}).ResolveKey())
+}
+
+func TestDir_XDGConfigHome(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	dir, err := Dir()
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/xdg", DirName), dir)
+}
+
+func TestCacheDir_XDGCacheHome(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	dir, err := CacheDir()
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/xdg-cache", DirName), dir)
+}
@@ -0,0 +1,36 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// CheckPermissions returns an error if the file at path can be read or
+// written by anyone other than its owner, or if its directory lets others
+// replace it (writable by group or others without the sticky bit).
+// Permission bits are not meaningful on Windows, where the check is skipped.
+func CheckPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Errorf("insecure permissions %04o on %s: run 'chmod 600 %s'", perm, path, path)
+	}
+
+	dir := filepath.Dir(path)
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if perm := dirInfo.Mode().Perm(); perm&0o022 != 0 && dirInfo.Mode()&os.ModeSticky == 0 {
+		return fmt.Errorf("insecure permissions %04o on %s: directory must not be writable by group or others", perm, dir)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultCommandTimeout = 10 * time.Second
+
+var (
+	commandCacheMu sync.Mutex
+	commandCache   = make(map[string]string)
+)
+
+// FromCommand runs command through the system shell and returns the first
+// line of its stdout as the API key. Results are cached for the life of the
+// process so the command runs at most once per invocation.
+func FromCommand(ctx context.Context, command string, timeout time.Duration) (string, error) {
+	commandCacheMu.Lock()
+	defer commandCacheMu.Unlock()
+
+	if key, ok := commandCache[command]; ok {
+		return key, nil
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// The command must not read the CLI's stdin, which may carry the input
+	// of the command being run. Helpers that prompt get the terminal.
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		c.Stdin = tty
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+	c.WaitDelay = time.Second
+
+	if err := c.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("key command timed out after %s", timeout)
+		}
+		return "", fmt.Errorf("key command failed: %w", err)
+	}
+
+	key := firstLine(stdout.Bytes())
+	if key == "" {
+		return "", fmt.Errorf("key command produced no output")
+	}
+
+	commandCache[command] = key
+	return key, nil
+}
+
+// FromFile reads the API key from the first line of the file at path, which
+// must not be accessible by group or others.
+func FromFile(path string) (string, error) {
+	if err := CheckPermissions(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("key file %s does not exist", path)
+		}
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key := firstLine(data)
+	if key == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
+
+func firstLine(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if scanner.Scan() {
+		return strings.TrimSpace(scanner.Text())
+	}
+	return ""
+}
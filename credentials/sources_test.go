@@ -0,0 +1,116 @@
+package credentials
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands use a POSIX shell")
+	}
+
+	t.Run("first line of stdout", func(t *testing.T) {
+		key, err := FromCommand(context.Background(), "printf 'secret\\nuser: me\\n'", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", key)
+	})
+
+	t.Run("cached for the process", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "runs")
+		command := "echo x >> " + marker + "; echo cached-key"
+
+		for i := 0; i < 3; i++ {
+			key, err := FromCommand(context.Background(), command, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, "cached-key", key)
+		}
+
+		data, err := os.ReadFile(marker)
+		require.NoError(t, err)
+		assert.Equal(t, "x\n", string(data))
+	})
+
+	t.Run("failing command", func(t *testing.T) {
+		_, err := FromCommand(context.Background(), "exit 3", time.Second)
+		assert.ErrorContains(t, err, "key command failed")
+	})
+
+	t.Run("empty output", func(t *testing.T) {
+		_, err := FromCommand(context.Background(), "true", time.Second)
+		assert.ErrorContains(t, err, "no output")
+	})
+
+	t.Run("does not read stdin", func(t *testing.T) {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			tty.Close()
+			t.Skip("the command would read the terminal")
+		}
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		_, err = w.WriteString("input\n")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = stdin }()
+
+		key, err := FromCommand(context.Background(), "cat; echo stdin-key", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "stdin-key", key)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "input\n", string(data))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := FromCommand(context.Background(), "exec sleep 5", 50*time.Millisecond)
+		assert.ErrorContains(t, err, "timed out")
+	})
+}
+
+func TestFromFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not checked on Windows")
+	}
+
+	dir := t.TempDir()
+
+	t.Run("reads first line", func(t *testing.T) {
+		path := filepath.Join(dir, "key")
+		require.NoError(t, os.WriteFile(path, []byte("  file-key  \nignored\n"), 0o600))
+
+		key, err := FromFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "file-key", key)
+	})
+
+	t.Run("insecure permissions", func(t *testing.T) {
+		path := filepath.Join(dir, "open-key")
+		require.NoError(t, os.WriteFile(path, []byte("file-key\n"), 0o644))
+
+		_, err := FromFile(path)
+		assert.ErrorContains(t, err, "insecure permissions")
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := filepath.Join(dir, "empty")
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+		_, err := FromFile(path)
+		assert.ErrorContains(t, err, "is empty")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := FromFile(filepath.Join(dir, "missing"))
+		assert.ErrorContains(t, err, "does not exist")
+	})
+}
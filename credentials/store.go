@@ -0,0 +1,248 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hastefuI/ffrelayctl/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	FileName = "credentials.json"
+
+	kdfScrypt  = "scrypt"
+	scryptN    = 1 << 15
+	scryptR    = 8
+	scryptP    = 1
+	keyLength  = 32
+	saltLength = 16
+)
+
+var (
+	ErrNotFound        = errors.New("no stored credentials")
+	ErrWrongPassphrase = errors.New("incorrect passphrase or corrupted credentials")
+	ErrEmptyPassphrase = errors.New("passphrase must not be empty")
+	errUnsupportedKDF  = errors.New("unsupported key derivation function")
+)
+
+type entry struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Store keeps API keys encrypted with a passphrase, one entry per config
+// context, in a single file readable only by the owner.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) Has(name string) (bool, error) {
+	entries, err := s.read()
+	if err != nil {
+		return false, err
+	}
+	_, ok := entries[name]
+	return ok, nil
+}
+
+func (s *Store) Save(name, key, passphrase string) error {
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	e, err := encrypt([]byte(key), passphrase)
+	if err != nil {
+		return err
+	}
+	entries[name] = e
+
+	return s.write(entries)
+}
+
+func (s *Store) Load(name, passphrase string) (string, error) {
+	entries, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	e, ok := entries[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	key, err := decrypt(e, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// Delete removes the entry for name, reporting whether one existed. The file
+// is removed once it holds no entries.
+func (s *Store) Delete(name string) (bool, error) {
+	entries, err := s.read()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := entries[name]; !ok {
+		return false, nil
+	}
+	delete(entries, name)
+
+	if len(entries) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to remove credentials file: %w", err)
+		}
+		return true, nil
+	}
+	return true, s.write(entries)
+}
+
+func (s *Store) read() (map[string]*entry, error) {
+	entries := make(map[string]*entry)
+
+	if err := CheckPermissions(s.path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", s.path, err)
+	}
+	return entries, nil
+}
+
+func (s *Store) write(entries map[string]*entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".credentials-*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+func encrypt(plaintext []byte, passphrase string) (*entry, error) {
+	e := &entry{KDF: kdfScrypt, N: scryptN, R: scryptR, P: scryptP}
+
+	e.Salt = make([]byte, saltLength)
+	if _, err := rand.Read(e.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newGCM(e, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	e.Ciphertext = gcm.Seal(nil, e.Nonce, plaintext, nil)
+
+	return e, nil
+}
+
+func decrypt(e *entry, passphrase string) ([]byte, error) {
+	gcm, err := newGCM(e, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	plaintext, err := gcm.Open(nil, e.Nonce, e.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+func newGCM(e *entry, passphrase string) (cipher.AEAD, error) {
+	if e.KDF != kdfScrypt {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedKDF, e.KDF)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), e.Salt, e.N, e.R, e.P, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Fingerprint identifies an API key without revealing it.
+func Fingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "SHA256:" + hex.EncodeToString(sum[:8])
+}
+
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
@@ -0,0 +1,115 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "ffrelayctl", FileName))
+
+	require.NoError(t, store.Save("personal", "secret-key", "correct horse"))
+	require.NoError(t, store.Save("work", "work-key", "battery staple"))
+
+	key, err := store.Load("personal", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", key)
+
+	key, err = store.Load("work", "battery staple")
+	require.NoError(t, err)
+	assert.Equal(t, "work-key", key)
+
+	data, err := os.ReadFile(store.Path())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-key")
+
+	info, err := os.Stat(store.Path())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestStore_Load(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, store.Save("default", "secret-key", "passphrase"))
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		_, err := store.Load("default", "wrong")
+		assert.ErrorIs(t, err, ErrWrongPassphrase)
+	})
+
+	t.Run("unknown entry", func(t *testing.T) {
+		_, err := store.Load("missing", "passphrase")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		empty := NewStore(filepath.Join(t.TempDir(), FileName))
+		_, err := empty.Load("default", "passphrase")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestStore_SaveEmptyPassphrase(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+
+	err := store.Save("default", "secret-key", "")
+
+	assert.ErrorIs(t, err, ErrEmptyPassphrase)
+	_, statErr := os.Stat(store.Path())
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestStore_Delete(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, store.Save("a", "key-a", "pw"))
+	require.NoError(t, store.Save("b", "key-b", "pw"))
+
+	removed, err := store.Delete("a")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	has, err := store.Has("a")
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	removed, err = store.Delete("a")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	removed, err = store.Delete("b")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	_, statErr := os.Stat(store.Path())
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not checked on Windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+
+	assert.NoError(t, CheckPermissions(path))
+
+	require.NoError(t, os.Chmod(path, 0o644))
+	assert.ErrorContains(t, CheckPermissions(path), "insecure permissions")
+
+	store := NewStore(path)
+	_, err := store.Has("default")
+	assert.ErrorContains(t, err, "insecure permissions")
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("key"), Fingerprint("key"))
+	assert.NotEqual(t, Fingerprint("key"), Fingerprint("other"))
+	assert.NotContains(t, Fingerprint("key"), "key")
+}
@@ -0,0 +1,230 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// record holds the fields of a value by JSON name, as strings, bools,
+// float64 numbers or nil. Other values, such as lists, cannot be compared.
+type record map[string]interface{}
+
+func (n andNode) eval(r record) (bool, error) {
+	ok, err := n.left.eval(r)
+	if err != nil || !ok {
+		return false, err
+	}
+	return n.right.eval(r)
+}
+
+func (n orNode) eval(r record) (bool, error) {
+	ok, err := n.left.eval(r)
+	if err != nil || ok {
+		return ok, err
+	}
+	return n.right.eval(r)
+}
+
+func (n notNode) eval(r record) (bool, error) {
+	ok, err := n.operand.eval(r)
+	return !ok, err
+}
+
+func (n fieldNode) eval(r record) (bool, error) {
+	switch v := r[n.field].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		return v != "", nil
+	case float64:
+		return v != 0, nil
+	}
+	return false, fmt.Errorf("field %s cannot be used in a filter", n.field)
+}
+
+func (n compareNode) eval(r record) (bool, error) {
+	v := r[n.field]
+	lit := n.value
+
+	if lit.isNull {
+		return (v == nil) == (n.op == "="), nil
+	}
+	if v == nil {
+		return n.op == "!=" || n.op == "!~", nil
+	}
+
+	switch v := v.(type) {
+	case bool:
+		if !lit.isBool || (n.op != "=" && n.op != "!=") {
+			return false, n.mismatch("true or false", "= or !=")
+		}
+		return (v == lit.boolean) == (n.op == "="), nil
+	case float64:
+		if !lit.isNumber || n.op == "~" || n.op == "!~" {
+			return false, n.mismatch("a number", "=, !=, <, <=, > or >=")
+		}
+		return compareOrdered(n.op, compare(v, lit.number)), nil
+	case string:
+		return n.evalString(v)
+	}
+	return false, fmt.Errorf("field %s cannot be used in a filter", n.field)
+}
+
+func (n compareNode) evalString(v string) (bool, error) {
+	lit := n.value
+	switch n.op {
+	case "~", "!~":
+		var matched bool
+		if lit.regex != nil {
+			matched = lit.regex.MatchString(v)
+		} else {
+			matched = strings.Contains(strings.ToLower(v), strings.ToLower(lit.text))
+		}
+		return matched == (n.op == "~"), nil
+	}
+	if lit.isDuration || lit.isTime {
+		t, err := time.Parse(time.RFC3339, v)
+		switch {
+		case err == nil:
+			return n.evalTime(t), nil
+		case lit.isDuration && v == "":
+			// An empty timestamp has no age, like null.
+			return false, nil
+		case lit.isDuration:
+			return false, fmt.Errorf("field %s is not a timestamp and cannot be compared with %s", n.field, lit.text)
+		}
+	}
+
+	if n.op == "=" || n.op == "!=" {
+		return strings.EqualFold(v, lit.text) == (n.op == "="), nil
+	}
+	return compareOrdered(n.op, strings.Compare(strings.ToLower(v), strings.ToLower(lit.text))), nil
+}
+
+func (n compareNode) evalTime(t time.Time) bool {
+	lit := n.value
+	if lit.isDuration {
+		// An age stands for the time that long ago, so < means earlier
+		// as with dates: last_used_at<90d means more than 90 days ago.
+		return compareOrdered(n.op, t.Compare(now().Add(-lit.duration)))
+	}
+	if lit.dateOnly && (n.op == "=" || n.op == "!=") {
+		sameDay := t.In(time.Local).Format("2006-01-02") == lit.time.Format("2006-01-02")
+		return sameDay == (n.op == "=")
+	}
+	return compareOrdered(n.op, t.Compare(lit.time))
+}
+
+func (n compareNode) mismatch(want, ops string) error {
+	return fmt.Errorf("field %s must be compared with %s using %s", n.field, want, ops)
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareOrdered(op string, c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// fields flattens v into a record. Fields of structs held in interface
+// fields are promoted, without replacing fields of the outer struct.
+func fields(v interface{}) record {
+	r := make(record)
+	addFields(r, reflect.ValueOf(v))
+	return r
+}
+
+func addFields(r record, rv reflect.Value) {
+	rv = indirect(rv)
+	switch rv.Kind() {
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			value := rv.Field(i)
+			if value.Kind() == reflect.Interface && indirect(value).Kind() == reflect.Struct {
+				addFields(r, value)
+				continue
+			}
+			if _, ok := r[name]; !ok {
+				r[name] = scalar(value)
+			}
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			r[iter.Key().String()] = scalar(iter.Value())
+		}
+	}
+}
+
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func scalar(rv reflect.Value) interface{} {
+	rv = indirect(rv)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return rv.Interface()
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,109 @@
+// Package filter implements the expression language of the --filter flag,
+// which selects list items by their JSON fields:
+//
+//	enabled=false and num_spam>0
+//	description~news or description~/^shop(ping)?$/
+//	last_used_at>90d and not blocked
+//
+// Comparisons are written field op value, with the operators =, !=, <, <=,
+// >, >=, ~ (contains, or matches a /regular expression/) and !~ (the
+// opposite of ~). Conditions are combined with and, or and not, and grouped
+// with parentheses. A field on its own tests whether it is true, non-empty
+// or non-zero.
+//
+// Values are numbers, true, false, null, words, and "quoted" or 'quoted'
+// strings. String comparisons, regular expressions included, ignore case
+// unless the expression starts with (?-i). Timestamps compare with dates
+// (2025-01-01), times (2025-01-01T12:00:00Z) or ages, which stand for the
+// time that long ago. < always means earlier: last_used_at<90d matches times
+// more than 90 days ago, and last_used_at>90d times within the last 90 days.
+// Age units are s, m, h, d, w and y.
+// Null values only match =null, !=, and !~.
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var now = time.Now
+
+// Expr is a parsed filter expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, &SyntaxError{Pos: 0, Msg: "empty filter"}
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected and, or or the end of the filter but found %s", t)}
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Validate checks that every field in the expression exists in at least
+// one of samples, which are values of the types that will be matched.
+func (e *Expr) Validate(samples ...interface{}) error {
+	known := make(map[string]bool)
+	for _, sample := range samples {
+		for name := range fields(sample) {
+			known[name] = true
+		}
+	}
+
+	for _, name := range fieldRefs(e.root) {
+		if !known[name] {
+			available := make([]string, 0, len(known))
+			for k := range known {
+				available = append(available, k)
+			}
+			sort.Strings(available)
+			return fmt.Errorf("unknown field %q in filter, available fields: %s", name, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// Match reports whether v satisfies the expression. v is a struct, whose
+// fields are addressed by their JSON names, or a map. The fields of a
+// struct held in an interface field are promoted, so a mask wrapped with
+// its type can be filtered by the mask's fields. Fields that v does not
+// have are null.
+func (e *Expr) Match(v interface{}) (bool, error) {
+	return e.root.eval(fields(v))
+}
+
+func fieldRefs(n node) []string {
+	switch n := n.(type) {
+	case andNode:
+		return append(fieldRefs(n.left), fieldRefs(n.right)...)
+	case orNode:
+		return append(fieldRefs(n.left), fieldRefs(n.right)...)
+	case notNode:
+		return fieldRefs(n.operand)
+	case fieldNode:
+		return []string{n.field}
+	case compareNode:
+		return []string{n.field}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wrapped struct {
+	Type string      `json:"type"`
+	Mask interface{} `json:"mask"`
+}
+
+func TestMatch(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	lastUsed := "2026-10-01T00:00:00Z"
+	mask := api.RelayAddress{
+		ID:           42,
+		FullAddress:  "abc123@mozmail.com",
+		Enabled:      false,
+		Description:  "Newsletter signups",
+		GeneratedFor: "",
+		CreatedAt:    "2025-01-15T08:00:00Z",
+		LastUsedAt:   &lastUsed,
+		NumForwarded: 12,
+		NumSpam:      3,
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"enabled=false", true},
+		{"enabled==true", false},
+		{"enabled!=true", true},
+		{"enabled=false and num_spam>0", true},
+		{"enabled=false and num_spam>3", false},
+		{"num_spam>=3 and num_forwarded<=12", true},
+		{"num_forwarded=12.0", true},
+		{"id!=42 or num_spam<1", false},
+		{"description~news", true},
+		{"description~NEWS", true},
+		{"description!~news", false},
+		{`description~"letter sign"`, true},
+		{"description~/^News(letter)?\\s/", true},
+		{"description~/^letter/", false},
+		{"description~/^NEWSLETTER SIGNUPS$/", true},
+		{"description~/(?-i)^NEWS/", false},
+		{"description='newsletter signups'", true},
+		{"full_address=ABC123@mozmail.com", true},
+		{"generated_for", false},
+		{"not generated_for", true},
+		{"description", true},
+		{"not enabled", true},
+		{"not (enabled or num_spam=0)", true},
+		{"enabled or num_spam>0 and num_forwarded>100", false},
+		{"(enabled or num_spam>0) and num_forwarded>10", true},
+		{"enabled=false OR enabled=true AND id=1", true},
+		{"last_used_at<90d", false},
+		{"last_used_at>90d", true},
+		{"last_used_at<2w", true},
+		{"last_used_at>2w", false},
+		{"created_at<2025-02-01", true},
+		{"created_at=2025-01-15", true},
+		{"created_at!=2025-01-15", false},
+		{"created_at>2025-01-15T09:00:00Z", false},
+		{"last_used_at!=null", true},
+		{"last_used_at=null", false},
+		{"used_on=null", false},
+		{"used_on=''", true},
+		{"missing=null", true},
+		{"missing!=1", true},
+		{"missing~x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := Parse(tt.filter)
+			require.NoError(t, err)
+			got, err := expr.Match(mask)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMatch_NullTimestamp(t *testing.T) {
+	mask := api.RelayAddress{ID: 1}
+	for _, filter := range []string{"last_used_at<90d", "last_used_at>90d", "created_at<90d"} {
+		expr, err := Parse(filter)
+		require.NoError(t, err)
+		got, err := expr.Match(mask)
+		require.NoError(t, err)
+		assert.False(t, got, filter)
+	}
+}
+
+func TestMatch_PromotesInterfaceFields(t *testing.T) {
+	expr, err := Parse("type=custom and enabled")
+	require.NoError(t, err)
+
+	got, err := expr.Match(wrapped{Type: "custom", Mask: &api.DomainAddress{Enabled: true}})
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	got, err = expr.Match(wrapped{Type: "random", Mask: api.RelayAddress{Enabled: true}})
+	require.NoError(t, err)
+	assert.False(t, got)
+}
+
+func TestMatch_Map(t *testing.T) {
+	expr, err := Parse("blocked and num_calls>2")
+	require.NoError(t, err)
+	got, err := expr.Match(map[string]interface{}{"blocked": true, "num_calls": 5})
+	require.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestMatch_NonASCIIWords(t *testing.T) {
+	mask := api.RelayAddress{Description: "Voilà, Åland ferry 日本語"}
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"description~voilà", true},
+		{"description~Åland", true},
+		{"description~åland and description~日本語", true},
+		{"description!~naïve", true},
+		{"description=Åland", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := Parse(tt.filter)
+			require.NoError(t, err)
+			got, err := expr.Match(mask)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMatch_TypeErrors(t *testing.T) {
+	contact := api.InboundContact{Blocked: true, NumCalls: 2, InboundNumber: "+15551234567"}
+
+	tests := []struct {
+		filter  string
+		wantErr string
+	}{
+		{"blocked=yes", "field blocked must be compared with true or false using = or !="},
+		{"blocked>true", "field blocked must be compared with true or false using = or !="},
+		{"num_calls=many", "field num_calls must be compared with a number using =, !=, <, <=, > or >="},
+		{"num_calls~2", "field num_calls must be compared with a number"},
+		{"inbound_number<90d", "field inbound_number is not a timestamp and cannot be compared with 90d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := Parse(tt.filter)
+			require.NoError(t, err)
+			_, err = expr.Match(contact)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		filter  string
+		wantErr string
+	}{
+		{"", "invalid filter: empty filter at column 1"},
+		{"enabled=", `invalid filter: expected a value after "=" but found end of filter at column 9`},
+		{"enabled and", "invalid filter: expected a field name but found end of filter at column 12"},
+		{"(enabled", `invalid filter: expected ")" but found end of filter at column 9`},
+		{"enabled num_spam>0", `invalid filter: expected and, or or the end of the filter but found "num_spam" at column 9`},
+		{"description~/[/", "invalid filter: invalid regular expression"},
+		{"description~/abc", "invalid filter: unterminated regular expression at column 13"},
+		{`description="abc`, "invalid filter: unterminated string at column 13"},
+		{"num_spam<null", `invalid filter: null can only be compared with = or !=, not "<" at column 9`},
+		{"last_used_at<999999999999y", `invalid filter: duration "999999999999y" is too long at column 14`},
+		{"last_used_at<99999999999999999999s", `invalid filter: duration "99999999999999999999s" is too long at column 14`},
+		{"last_used_at=90d", `invalid filter: durations stand for times that long ago and need <, <=, > or >=, not "=" at column 13`},
+		{"2fa=true", `invalid filter: invalid field name "2fa" at column 1`},
+		{"enabled=!", `invalid filter: unexpected '!' at column 9`},
+		{"enabled=\u00a0true", `invalid filter: unexpected '\u00a0' at column 9`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := Parse(tt.filter)
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	expr, err := Parse("type=custom and used_on~shop")
+	require.NoError(t, err)
+	assert.NoError(t, expr.Validate(wrapped{Mask: api.RelayAddress{}}, wrapped{Mask: api.DomainAddress{}}))
+
+	expr, err = Parse("enabled and nmu_spam>0")
+	require.NoError(t, err)
+	err = expr.Validate(api.RelayNumber{})
+	assert.ErrorContains(t, err, `unknown field "nmu_spam" in filter, available fields: calls_blocked, calls_forwarded, country_code`)
+}
@@ -0,0 +1,140 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenRegex
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return fmt.Sprintf("%q", t.text)
+	case tokenRegex:
+		return "/" + t.text + "/"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are ordered so that longer operators match first.
+var operators = []string{"==", "!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+// lex splits src into tokens. Words are runs of characters other than
+// spaces, parentheses, quotes and operator characters, so values such as
+// +15551234567, 2025-01-01 or 90d need no quoting. A "/" right after a
+// match operator starts a regular expression.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := lexQuoted(src[i:], c)
+			if err != nil {
+				return nil, &SyntaxError{Pos: i, Msg: err.Error()}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i += n
+		case c == '/' && afterMatchOp(tokens):
+			text, n, err := lexQuoted(src[i:], '/')
+			if err != nil {
+				return nil, &SyntaxError{Pos: i, Msg: "unterminated regular expression"}
+			}
+			tokens = append(tokens, token{kind: tokenRegex, text: text, pos: i})
+			i += n
+		case strings.ContainsRune("=!<>~", rune(c)):
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected %q", c)}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		default:
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if !isWordChar(r) {
+					break
+				}
+				i += size
+			}
+			if i == start {
+				r, _ := utf8.DecodeRuneInString(src[i:])
+				return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected %q", r)}
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: src[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isWordChar(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(`()"'=!<>~`, r)
+}
+
+func afterMatchOp(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenOp && (last.text == "~" || last.text == "!~")
+}
+
+// lexQuoted reads text delimited by quote, where a backslash escapes the
+// quote or another backslash. It returns the text and the number of bytes
+// consumed, including the quotes.
+func lexQuoted(src string, quote byte) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch {
+		case src[i] == '\\' && i+1 < len(src) && (src[i+1] == quote || src[i+1] == '\\'):
+			// Backslashes are kept in regular expressions, where they
+			// may escape other characters too.
+			if quote == '/' && src[i+1] == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(src[i+1])
+			i++
+		case src[i] == quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(src[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
@@ -0,0 +1,258 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyntaxError reports an invalid filter expression. Pos is the byte offset
+// of the problem in the expression.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter: %s at column %d", e.Msg, e.Pos+1)
+}
+
+type node interface {
+	eval(r record) (bool, error)
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ operand node }
+
+// fieldNode tests a field on its own, as in "enabled" or "not blocked".
+type fieldNode struct{ field string }
+
+type compareNode struct {
+	field string
+	op    string
+	value literal
+}
+
+// literal is a value in a comparison, with every interpretation of its
+// text that applies worked out when the filter is parsed.
+type literal struct {
+	text string
+
+	isNull   bool
+	isBool   bool
+	boolean  bool
+	isNumber bool
+	number   float64
+
+	// A duration compares the age of a timestamp; a date or time
+	// compares the timestamp itself. dateOnly dates match a whole day
+	// with = and !=.
+	isDuration bool
+	duration   time.Duration
+	isTime     bool
+	time       time.Time
+	dateOnly   bool
+
+	regex *regexp.Regexp
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) keyword(name string) bool {
+	t := p.peek()
+	if t.kind == tokenWord && strings.EqualFold(t.text, name) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// parseOr parses the lowest precedence level: and binds tighter than or,
+// and not tighter than both.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.keyword("not") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\" but found %s", closing)}
+		}
+		return n, nil
+	case tokenWord:
+		if !isFieldName(t.text) {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("invalid field name %q", t.text)}
+		}
+	default:
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected a field name but found %s", t)}
+	}
+
+	field := strings.ToLower(t.text)
+	if p.peek().kind != tokenOp {
+		return fieldNode{field}, nil
+	}
+	op := p.next()
+	value := p.next()
+	lit, err := parseLiteral(op, value)
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{field: field, op: normalizeOp(op.text), value: lit}, nil
+}
+
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func isFieldName(s string) bool {
+	return fieldNamePattern.MatchString(s)
+}
+
+func normalizeOp(op string) string {
+	if op == "==" {
+		return "="
+	}
+	return op
+}
+
+func parseLiteral(op, t token) (literal, error) {
+	lit := literal{text: t.text}
+	switch t.kind {
+	case tokenRegex:
+		// Like other string comparisons, matches ignore case; (?-i) in
+		// the expression turns that off.
+		re, err := regexp.Compile("(?i)" + t.text)
+		if err != nil {
+			return lit, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("invalid regular expression: %v", err)}
+		}
+		lit.regex = re
+		return lit, nil
+	case tokenString:
+		return lit, nil
+	case tokenWord:
+	default:
+		return lit, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected a value after %q but found %s", op.text, t)}
+	}
+
+	switch strings.ToLower(t.text) {
+	case "null":
+		lit.isNull = true
+	case "true", "false":
+		lit.isBool = true
+		lit.boolean = strings.EqualFold(t.text, "true")
+	}
+	if n, err := strconv.ParseFloat(t.text, 64); err == nil {
+		lit.isNumber = true
+		lit.number = n
+	}
+	if d, ok, err := parseDuration(t.text); err != nil {
+		return lit, &SyntaxError{Pos: t.pos, Msg: err.Error()}
+	} else if ok {
+		lit.isDuration = true
+		lit.duration = d
+	}
+	if tm, err := time.Parse(time.RFC3339, t.text); err == nil {
+		lit.isTime = true
+		lit.time = tm
+	} else if tm, err := time.ParseInLocation("2006-01-02", t.text, time.Local); err == nil {
+		lit.isTime = true
+		lit.time = tm
+		lit.dateOnly = true
+	}
+
+	switch {
+	case lit.isNull && op.text != "=" && op.text != "==" && op.text != "!=":
+		return lit, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("null can only be compared with = or !=, not %q", op.text)}
+	case lit.isDuration && !isOrdering(op.text):
+		return lit, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("durations stand for times that long ago and need <, <=, > or >=, not %q", op.text)}
+	}
+	return lit, nil
+}
+
+func isOrdering(op string) bool {
+	return op == "<" || op == "<=" || op == ">" || op == ">="
+}
+
+var durationPattern = regexp.MustCompile(`^(\d+)(s|m|h|d|w|y)$`)
+
+// parseDuration accepts a number followed by s, m (minutes), h, d, w or y,
+// such as 90d. It reports an error for durations too long to represent.
+func parseDuration(s string) (time.Duration, bool, error) {
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false, nil
+	}
+	unit := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+		"y": 365 * 24 * time.Hour,
+	}[m[2]]
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil || n > math.MaxInt64/int64(unit) {
+		return 0, false, fmt.Errorf("duration %q is too long", s)
+	}
+	return time.Duration(n) * unit, true, nil
+}
@@ -0,0 +1,161 @@
+// Package fuzzy ranks items by how well their text fields match a search
+// query, tolerating missing characters the way fuzzy finders do: "untd"
+// matches "United Airlines".
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 12
+	bonusBoundary    = 10
+	bonusStart       = 4
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+
+	// minScorePerChar rejects matches whose characters are scattered too
+	// widely to be meaningful.
+	minScorePerChar = 10
+
+	// maxRecencyBonus is added for items used just now, decreasing to
+	// nothing for items last used a year ago or more.
+	maxRecencyBonus = 20
+	recencyWindow   = 365 * 24 * time.Hour
+)
+
+var now = time.Now
+
+// Score reports whether the characters of pattern appear in order in text,
+// ignoring case, and how well: consecutive characters and matches at the
+// start of words score higher, gaps lower.
+func Score(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	best, found := 0, false
+	for start := range t {
+		if t[start] != p[0] {
+			continue
+		}
+		if score, ok := scoreFrom(p, t, start); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	if !found || best < minScorePerChar*len(p) {
+		return 0, false
+	}
+	return best, true
+}
+
+// scoreFrom matches p greedily in t, starting with p[0] at t[start].
+func scoreFrom(p, t []rune, start int) (int, bool) {
+	score := 0
+	prev := -1
+	j := start
+	for _, c := range p {
+		for j < len(t) && t[j] != c {
+			j++
+		}
+		if j == len(t) {
+			return 0, false
+		}
+
+		score += scoreMatch
+		switch {
+		case prev >= 0 && j == prev+1:
+			score += bonusConsecutive
+		case prev >= 0:
+			score -= penaltyGapStart + penaltyGapExtend*(j-prev-2)
+		}
+		if j == 0 {
+			score += bonusStart + bonusBoundary
+		} else if isBoundary(t[j-1], t[j]) {
+			score += bonusBoundary
+		}
+		prev = j
+		j++
+	}
+	return score, true
+}
+
+func isBoundary(prev, cur rune) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	return !isWord(prev) && isWord(cur)
+}
+
+// Candidate is the searchable view of an item: its text fields and when it
+// was last used, or the zero time if never.
+type Candidate struct {
+	Fields   []string
+	LastUsed time.Time
+}
+
+// Rank returns the items matching query, best first. Every word of the
+// query must match one of an item's fields; an item scores the sum of each
+// word's best match, plus a bonus for recent use. Ties are broken by more
+// recent use, then by the original order.
+func Rank[T any](query string, items []T, candidate func(T) Candidate) []T {
+	terms := strings.Fields(query)
+
+	type ranked struct {
+		item     T
+		score    int
+		lastUsed time.Time
+	}
+	var results []ranked
+	for _, item := range items {
+		c := candidate(item)
+		total, ok := 0, true
+		for _, term := range terms {
+			best, matched := 0, false
+			for _, field := range c.Fields {
+				if score, ok := Score(term, field); ok && (!matched || score > best) {
+					best, matched = score, true
+				}
+			}
+			if !matched {
+				ok = false
+				break
+			}
+			total += best
+		}
+		if ok {
+			results = append(results, ranked{item: item, score: total + recencyBonus(c.LastUsed), lastUsed: c.LastUsed})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].lastUsed.After(results[j].lastUsed)
+	})
+
+	out := make([]T, len(results))
+	for i, r := range results {
+		out[i] = r.item
+	}
+	return out
+}
+
+func recencyBonus(lastUsed time.Time) int {
+	if lastUsed.IsZero() {
+		return 0
+	}
+	age := now().Sub(lastUsed)
+	if age < 0 {
+		age = 0
+	}
+	if age >= recencyWindow {
+		return 0
+	}
+	return int(float64(maxRecencyBonus) * (1 - float64(age)/float64(recencyWindow)))
+}
@@ -0,0 +1,84 @@
+package fuzzy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		match   bool
+	}{
+		{"united", "Airline tickets, United", true},
+		{"UNITED", "united.com", true},
+		{"untd", "United Airlines", true},
+		{"air", "Airline tickets", true},
+		{"", "anything", true},
+		{"xyz", "Airline tickets", false},
+		{"tenu", "United", false},
+		{"bez", "abcdefghijklmnopqrstuvwxyz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.text, func(t *testing.T) {
+			_, ok := Score(tt.pattern, tt.text)
+			assert.Equal(t, tt.match, ok)
+		})
+	}
+}
+
+func TestScore_Ordering(t *testing.T) {
+	score := func(pattern, text string) int {
+		s, ok := Score(pattern, text)
+		assert.True(t, ok, "%q should match %q", pattern, text)
+		return s
+	}
+
+	// Contiguous beats scattered.
+	assert.Greater(t, score("news", "newsletter"), score("news", "new users"))
+	// Matching at a word start beats matching inside a word.
+	assert.Greater(t, score("line", "air line"), score("line", "airline"))
+	// Matching at the start of the text beats a later word start.
+	assert.Greater(t, score("shop", "shop online"), score("shop", "online shop"))
+}
+
+type item struct {
+	name     string
+	fields   []string
+	lastUsed time.Time
+}
+
+func candidate(i item) Candidate {
+	return Candidate{Fields: i.fields, LastUsed: i.lastUsed}
+}
+
+func names(items []item) []string {
+	var out []string
+	for _, i := range items {
+		out = append(out, i.name)
+	}
+	return out
+}
+
+func TestRank(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+	recent := now().Add(-24 * time.Hour)
+	old := now().Add(-300 * 24 * time.Hour)
+
+	items := []item{
+		{name: "bank", fields: []string{"bank@mozmail.com", "Online banking"}, lastUsed: recent},
+		{name: "united-old", fields: []string{"ua1@mozmail.com", "United Airlines", "united.com"}, lastUsed: old},
+		{name: "united-new", fields: []string{"ua2@mozmail.com", "United Airlines", "united.com"}, lastUsed: recent},
+		{name: "unused", fields: []string{"x@mozmail.com", "Used once at a united event"}},
+		{name: "airline", fields: []string{"fly@mozmail.com", "Airline miles"}, lastUsed: recent},
+	}
+
+	assert.Equal(t, []string{"united-new", "united-old", "unused"}, names(Rank("united", items, candidate)))
+	assert.Equal(t, []string{"united-new", "united-old"}, names(Rank("united air", items, candidate)))
+	assert.Equal(t, []string{"bank"}, names(Rank("bank online", items, candidate)))
+	assert.Empty(t, Rank("nothing", items, candidate))
+}
@@ -0,0 +1,166 @@
+// Package journal records the progress of long-running operations in a
+// JSON Lines file, so that an interrupted operation can be resumed: a
+// header with the command and its plan, then an entry per finished item.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const Version = 1
+
+var ErrInvalid = errors.New("not a journal")
+
+// Header describes the operation a journal records. BaseURL and
+// KeyFingerprint identify the account it acts on, so that it is not
+// resumed against another one.
+type Header struct {
+	Version        int             `json:"version"`
+	Command        string          `json:"command"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Keep           bool            `json:"keep"`
+	BaseURL        string          `json:"base_url"`
+	KeyFingerprint string          `json:"key_fingerprint"`
+	Plan           json.RawMessage `json:"plan"`
+}
+
+// Entry records that the item at index Item of the plan finished, and
+// whether it succeeded.
+type Entry struct {
+	Item  int    `json:"item"`
+	Done  bool   `json:"done"`
+	Error string `json:"error,omitempty"`
+}
+
+// Journal appends entries to a journal file. It is safe for concurrent
+// use.
+type Journal struct {
+	mu     sync.Mutex
+	f      *os.File
+	header Header
+}
+
+// Create writes a new journal at path with header and plan; the version,
+// creation time and plan of header are set here. Keep marks a journal the
+// user asked for, which should not be removed once the operation completes.
+func Create(path string, header Header, plan interface{}) (*Journal, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	header.Version, header.CreatedAt, header.Plan = Version, time.Now().UTC(), data
+	line, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	return &Journal{f: f, header: header}, nil
+}
+
+// Open reopens the journal at path for appending and returns the items
+// that already succeeded. A last line cut short by a crash is ignored.
+func Open(path string) (*Journal, map[int]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var header Header
+	done := make(map[int]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for n := 0; scanner.Scan(); n++ {
+		if n == 0 {
+			if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version == 0 || header.Command == "" {
+				return nil, nil, fmt.Errorf("%s: %w", path, ErrInvalid)
+			}
+			if header.Version > Version {
+				return nil, nil, fmt.Errorf("%s: unsupported journal version %d", path, header.Version)
+			}
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		done[entry.Item] = entry.Done
+	}
+	if header.Version == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", path, ErrInvalid)
+	}
+	for item, ok := range done {
+		if !ok {
+			delete(done, item)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		// Terminate a partial line so the next entry starts on its own.
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
+	return &Journal{f: f, header: header}, done, nil
+}
+
+func (j *Journal) Header() Header {
+	return j.header
+}
+
+func (j *Journal) Path() string {
+	return j.f.Name()
+}
+
+// Record appends an entry. Each entry is written with a single write, so
+// it is not lost if the process exits afterwards.
+func (j *Journal) Record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Close flushes the journal to disk and closes it.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.f.Sync(); err != nil {
+		j.f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return j.f.Close()
+}
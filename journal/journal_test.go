@@ -0,0 +1,90 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type plan struct {
+	IDs []int `json:"ids"`
+}
+
+func TestCreateAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journals", "j.jsonl")
+
+	j, err := Create(path, Header{Command: "masks delete", BaseURL: "https://relay.example", KeyFingerprint: "SHA256:00"}, plan{IDs: []int{1, 2, 3}})
+	require.NoError(t, err)
+	require.NoError(t, j.Record(Entry{Item: 0, Done: true}))
+	require.NoError(t, j.Record(Entry{Item: 1, Error: "boom"}))
+	require.NoError(t, j.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	j, done, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true}, done)
+	assert.Equal(t, "masks delete", j.Header().Command)
+	assert.Equal(t, "https://relay.example", j.Header().BaseURL)
+	assert.Equal(t, "SHA256:00", j.Header().KeyFingerprint)
+	assert.Equal(t, Version, j.Header().Version)
+	var p plan
+	require.NoError(t, json.Unmarshal(j.Header().Plan, &p))
+	assert.Equal(t, []int{1, 2, 3}, p.IDs)
+
+	// A retried item that now succeeds is done.
+	require.NoError(t, j.Record(Entry{Item: 1, Done: true}))
+	require.NoError(t, j.Close())
+	_, done, err = Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true, 1: true}, done)
+}
+
+func TestCreate_Exists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "j.jsonl")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	_, err := Create(path, Header{Command: "batch", Keep: true}, nil)
+	assert.ErrorContains(t, err, "failed to create journal")
+}
+
+func TestOpen_PartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "j.jsonl")
+	j, err := Create(path, Header{Command: "batch", Keep: true}, nil)
+	require.NoError(t, err)
+	require.NoError(t, j.Record(Entry{Item: 0, Done: true}))
+	require.NoError(t, j.Close())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"item":1,"do`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, done, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true}, done)
+	require.NoError(t, j.Record(Entry{Item: 1, Done: true}))
+	require.NoError(t, j.Close())
+
+	_, done, err = Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{0: true, 1: true}, done)
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "j.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"op\":\"delete-mask\"}\n"), 0o600))
+
+	_, _, err := Open(path)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, _, err = Open(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.ErrorContains(t, err, "failed to read journal")
+}
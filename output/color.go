@@ -0,0 +1,74 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+const (
+	styleReset     = "\x1b[0m"
+	styleBold      = "\x1b[1m"
+	styleDim       = "\x1b[2m"
+	styleRed       = "\x1b[31m"
+	styleHighlight = "\x1b[1;32m"
+)
+
+func ColorModes() []string {
+	return []string{ColorAuto, ColorAlways, ColorNever}
+}
+
+func validateColorMode(mode string) error {
+	for _, m := range ColorModes() {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid color mode %q: must be one of [%s]", mode, strings.Join(ColorModes(), "|"))
+}
+
+// useColor decides whether to style output written to w. In auto mode,
+// color is used only on a terminal, and never when NO_COLOR is set or TERM
+// is "dumb".
+func useColor(mode string, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// rowStyle dims disabled masks and phone numbers and shows blocked contacts
+// in red. It looks at the whole row, so the style applies even when the
+// field is not among the displayed columns.
+func rowStyle(t *table, row []interface{}) string {
+	for i, field := range t.fields {
+		switch {
+		case field == "enabled" && row[i] == false:
+			return styleDim
+		case field == "blocked" && row[i] == true:
+			return styleRed
+		}
+	}
+	return ""
+}
+
+// cellStyle highlights premium flags.
+func cellStyle(field string, v interface{}) string {
+	if field == "has_premium" && v == true {
+		return styleHighlight
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ansi = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestColor_Styles(t *testing.T) {
+	masks := []api.RelayAddress{
+		{ID: 1, FullAddress: "on@mozmail.com", Enabled: true},
+		{ID: 2, FullAddress: "off@mozmail.com"},
+	}
+	out := printWith(t, FormatText, masks, WithColor(ColorAlways), WithColumns([]string{"id", "full_address"}))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], styleBold))
+	assert.NotContains(t, lines[1], "\x1b")
+	assert.True(t, strings.HasPrefix(lines[2], styleDim), "disabled mask is dimmed even without the enabled column")
+
+	contacts := []api.InboundContact{{ID: 1, Blocked: true}}
+	out = printWith(t, FormatText, contacts, WithColor(ColorAlways), WithNoHeaders(true))
+	assert.True(t, strings.HasPrefix(out, styleRed))
+
+	profiles := []api.Profile{{ID: 1, HasPremium: true}}
+	out = printWith(t, FormatText, profiles, WithColor(ColorAlways))
+	assert.Contains(t, out, styleHighlight+"true"+styleReset)
+}
+
+func TestColor_KeepsAlignment(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	masks := []CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{ID: 1, FullAddress: "a@mozmail.com", Enabled: true}},
+		{Type: "custom", Mask: api.DomainAddress{ID: 22, FullAddress: "longer@me.mozmail.com"}},
+	}
+	plain := printWith(t, FormatText, masks, WithColor(ColorNever))
+	colored := printWith(t, FormatText, masks, WithColor(ColorAlways))
+	assert.NotEqual(t, plain, colored)
+	assert.Equal(t, plain, ansi.ReplaceAllString(colored, ""))
+}
+
+func TestColor_AutoAndNoColor(t *testing.T) {
+	profiles := []api.Profile{{ID: 1, HasPremium: true}}
+	assert.NotContains(t, printWith(t, FormatText, profiles), "\x1b", "auto mode does not color non-terminals")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, useColor(ColorAuto, nil))
+	assert.True(t, useColor(ColorAlways, nil))
+}
+
+func TestColor_InvalidMode(t *testing.T) {
+	_, err := NewPrinter(FormatText, WithColor("sometimes"))
+	assert.ErrorContains(t, err, "invalid color mode")
+}
+
+func TestColor_NotUsedByMachineFormats(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewPrinter(FormatCSV, WithColor(ColorAlways))
+	require.NoError(t, err)
+	require.NoError(t, p.Fprint(&buf, []api.InboundContact{{ID: 1, Blocked: true}}))
+	assert.NotContains(t, buf.String(), "\x1b")
+}
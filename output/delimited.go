@@ -0,0 +1,74 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hastefuI/ffrelayctl/api"
+)
+
+// printDelimited writes v as CSV or TSV with a header row of JSON field
+// names. Values containing the delimiter, quotes or newlines are quoted.
+func printDelimited(w io.Writer, format string, comma rune, v interface{}, opts printOptions) error {
+	t, ok := buildTable(v)
+	if !ok {
+		return unsupportedFormatError(format)
+	}
+
+	columns := t.fields
+	if len(opts.columns) > 0 {
+		columns = opts.columns
+	}
+	indexes, err := selectColumns(t, columns)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if !opts.noHeaders {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.rows {
+		record := make([]string, len(indexes))
+		for i, idx := range indexes {
+			record[i] = formatCell(row[idx])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// unsupportedFormatError reports that v cannot be printed in a delimited
+// format because it has no tabular form.
+func unsupportedFormatError(format string) error {
+	return &OptionError{fmt.Errorf("%s output is not supported for this command", format)}
+}
+
+// formatCell renders a table cell for machine-readable formats: null is
+// empty and nested JSON values are written as compact JSON.
+func formatCell(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case api.BounceStatus:
+		return formatBounceStatus(value)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readDelimited(t *testing.T, data []byte, comma rune) [][]string {
+	t.Helper()
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	records, err := r.ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestPrintCSV_CombinedMasks(t *testing.T) {
+	lastUsed := "2025-01-02T00:00:00Z"
+	masks := []CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{
+			ID:           1,
+			FullAddress:  "abc@mozmail.com",
+			Description:  "Shopping, \"deals\"\nand more",
+			GeneratedFor: "amazon.com",
+			LastUsedAt:   &lastUsed,
+		}},
+		{Type: "custom", Mask: &api.DomainAddress{ID: 2, FullAddress: "shop@me.mozmail.com"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatCSV, masks))
+
+	records := readDelimited(t, buf.Bytes(), ',')
+	require.Len(t, records, 3)
+
+	header := records[0]
+	assert.Equal(t, "type", header[0])
+	col := func(name string) int {
+		for i, h := range header {
+			if h == name {
+				return i
+			}
+		}
+		t.Fatalf("missing column %q", name)
+		return -1
+	}
+
+	assert.Equal(t, "random", records[1][col("type")])
+	assert.Equal(t, "Shopping, \"deals\"\nand more", records[1][col("description")])
+	assert.Equal(t, lastUsed, records[1][col("last_used_at")])
+	assert.Equal(t, "custom", records[2][col("type")])
+	assert.Equal(t, "", records[2][col("generated_for")])
+	assert.Equal(t, "", records[2][col("last_used_at")])
+}
+
+func TestPrintTSV_Columns(t *testing.T) {
+	locality := "Tyler"
+	tests := []struct {
+		name   string
+		v      interface{}
+		header []string
+		rows   int
+	}{
+		{
+			name:   "relay numbers",
+			v:      []api.RelayNumber{{ID: 1, Number: "+15550001111"}},
+			header: []string{"id", "number", "enabled", "location", "vendor_id", "country_code", "created_at", "remaining_texts", "remaining_minutes", "calls_forwarded", "calls_blocked", "texts_forwarded", "texts_blocked"},
+			rows:   1,
+		},
+		{
+			name:   "inbound contacts",
+			v:      []api.InboundContact{{ID: 1}, {ID: 2}},
+			header: []string{"id", "relay_number", "inbound_number", "last_inbound_date", "last_inbound_type", "num_calls", "num_calls_blocked", "last_call_date", "num_texts", "num_texts_blocked", "last_text_date", "blocked"},
+			rows:   2,
+		},
+		{
+			name:   "real phones",
+			v:      []api.RealPhone{},
+			header: []string{"id", "number", "verification_sent_date", "verified", "verified_date", "country_code"},
+			rows:   0,
+		},
+		{
+			name:   "phone number options",
+			v:      []api.PhoneNumberOption{{PhoneNumber: "+14305550101", Locality: &locality}},
+			header: []string{"friendly_name", "iso_country", "locality", "phone_number", "postal_code", "region"},
+			rows:   1,
+		},
+		{
+			name: "suggestions",
+			v: &api.RelayNumberSuggestions{
+				SameAreaOptions: []api.PhoneNumberOption{{PhoneNumber: "+14305550101"}},
+				RandomOptions:   []api.PhoneNumberOption{{PhoneNumber: "+12065550101"}},
+			},
+			header: []string{"category", "friendly_name", "iso_country", "locality", "phone_number", "postal_code", "region"},
+			rows:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Fprint(&buf, FormatTSV, tt.v))
+
+			records := readDelimited(t, buf.Bytes(), '\t')
+			require.Len(t, records, tt.rows+1)
+			assert.Equal(t, tt.header, records[0])
+		})
+	}
+}
+
+func TestPrintCSV_Unsupported(t *testing.T) {
+	export := struct {
+		Masks []CombinedMask `json:"masks"`
+	}{}
+
+	var buf bytes.Buffer
+	err := Fprint(&buf, FormatCSV, export)
+
+	assert.ErrorContains(t, err, "csv output is not supported")
+}
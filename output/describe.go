@@ -0,0 +1,130 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hastefuI/ffrelayctl/api"
+)
+
+var labelOverrides = map[string]string{
+	"id":          "ID",
+	"avatar":      "Avatar URL",
+	"base_url":    "Base URL",
+	"vendor_id":   "Vendor ID",
+	"iso_country": "ISO Country",
+}
+
+// describe prints every field of a single object as an aligned "Label: value"
+// list, in struct order.
+func describe[T any](w io.Writer, v T, opts printOptions) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeFields(tw, reflect.ValueOf(v), opts)
+	return tw.Flush()
+}
+
+func writeFields(w io.Writer, rv reflect.Value, opts printOptions) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		value := rv.Field(i)
+		if value.Kind() == reflect.Interface && !value.IsNil() {
+			value = value.Elem()
+		}
+		for value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct && !isScalarStruct(value.Type()) {
+			writeFields(w, value, opts)
+			continue
+		}
+
+		text := formatValue(value)
+		if value.Kind() == reflect.String && value.String() != "" && isTimeField(name) {
+			text = opts.formatTimeDetail(value.String())
+		}
+		lines := strings.Split(text, "\n")
+		fmt.Fprintf(w, "%s:\t%s\n", fieldLabel(name), strings.Join(lines, "\n\t"))
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func fieldLabel(name string) string {
+	if label, ok := labelOverrides[name]; ok {
+		return label
+	}
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isScalarStruct reports whether a struct type is rendered as a single value
+// rather than expanded into its fields.
+func isScalarStruct(t reflect.Type) bool {
+	return t == reflect.TypeOf(api.BounceStatus{})
+}
+
+func formatValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "-"
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "-"
+		}
+		return formatValue(v.Elem())
+	case reflect.String:
+		return orDash(strings.TrimSpace(v.String()))
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return "-"
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(items, ", ")
+	}
+
+	if status, ok := v.Interface().(api.BounceStatus); ok {
+		return formatBounceStatus(status)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func formatBounceStatus(status api.BounceStatus) string {
+	if !status.Paused {
+		return "not paused"
+	}
+	if status.Type == "" {
+		return "paused"
+	}
+	return fmt.Sprintf("paused (%s bounce)", status.Type)
+}
@@ -0,0 +1,225 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// document is a format-independent page of titled sections, rendered by
+// the markdown and html formats.
+type document struct {
+	Title     string
+	Heading   string
+	Generated string
+	Sections  []section
+}
+
+// section holds a table, a preformatted block for values with no tabular
+// form, or a message when there is nothing to show.
+type section struct {
+	Title string
+	Table *renderedTable
+	Pre   string
+	Empty string
+}
+
+// buildDocument always uses absolute times, since documents are kept and
+// shared rather than read immediately.
+func buildDocument(v interface{}, opts printOptions) (*document, error) {
+	opts.absoluteTimes = true
+	doc := &document{Title: "Firefox Relay"}
+	value := deref(v)
+	if export, ok := value.(AccountExport); ok {
+		return accountReport(export, opts)
+	}
+
+	s, err := valueSection(value, opts)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error formatting output: %v", err)
+		}
+		s = &section{Pre: string(data)}
+	}
+	doc.Sections = append(doc.Sections, *s)
+	return doc, nil
+}
+
+// valueSection renders lists with their text view columns and single
+// objects as a field/value table. It returns nil for values with no
+// tabular form.
+func valueSection(value interface{}, opts printOptions) (*section, error) {
+	if value == nil {
+		return nil, nil
+	}
+	t, ok := buildTable(value)
+	if !ok {
+		return nil, nil
+	}
+
+	if view, ok := listViews[reflect.TypeOf(value)]; ok {
+		if len(t.rows) == 0 {
+			return &section{Empty: view.empty}, nil
+		}
+		rt, err := renderTable(t, view.columnsFor(opts), view.cells, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &section{Table: rt}, nil
+	}
+
+	columns := opts.columns
+	if len(columns) == 0 {
+		if reflect.ValueOf(value).Kind() == reflect.Struct {
+			return &section{Table: fieldTable(t, opts)}, nil
+		}
+		columns = t.fields
+	}
+	rt, err := renderTable(t, columns, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &section{Table: rt}, nil
+}
+
+// fieldTable renders the single row of t vertically, one field per row.
+func fieldTable(t *table, opts printOptions) *renderedTable {
+	rt := &renderedTable{}
+	if !opts.noHeaders {
+		rt.Headers = []string{"FIELD", "VALUE"}
+	}
+	for i, name := range t.fields {
+		rt.Rows = append(rt.Rows, []string{fieldLabel(name), opts.textCell(name, t.rows[0][i])})
+	}
+	return rt
+}
+
+func printMarkdown(w io.Writer, v interface{}, opts printOptions) error {
+	doc, err := buildDocument(v, opts)
+	if err != nil {
+		return err
+	}
+
+	var blocks []string
+	if doc.Heading != "" {
+		blocks = append(blocks, "# "+escapeMarkdown(doc.Heading))
+	}
+	if doc.Generated != "" {
+		blocks = append(blocks, "_Generated "+doc.Generated+"_")
+	}
+	for _, s := range doc.Sections {
+		if s.Title != "" {
+			blocks = append(blocks, "## "+escapeMarkdown(s.Title))
+		}
+		switch {
+		case s.Table != nil:
+			blocks = append(blocks, markdownTable(s.Table))
+		case s.Pre != "":
+			blocks = append(blocks, "```json\n"+s.Pre+"\n```")
+		default:
+			blocks = append(blocks, "_"+escapeMarkdown(s.Empty)+"_")
+		}
+	}
+	_, err = fmt.Fprintln(w, strings.Join(blocks, "\n\n"))
+	return err
+}
+
+func markdownTable(rt *renderedTable) string {
+	var sb strings.Builder
+	writeRow := func(cells []string) {
+		sb.WriteString("|")
+		for _, cell := range cells {
+			sb.WriteString(" " + escapeMarkdown(cell) + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rt.Headers)
+	sb.WriteString("|" + strings.Repeat(" --- |", len(rt.Headers)) + "\n")
+	for _, row := range rt.Rows {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", " ",
+	"\n", " ",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; margin: 2rem; color: #1d1d1f; }
+h1 { font-size: 1.5rem; }
+h2 { font-size: 1.2rem; margin-top: 2rem; }
+table { border-collapse: collapse; margin: 0.5rem 0 1rem; font-size: 0.875rem; }
+th, td { border: 1px solid #d0d0d7; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f0f0f4; }
+pre { background: #f0f0f4; padding: 1rem; overflow-x: auto; }
+.meta, .empty { color: #5b5b66; }
+</style>
+</head>
+<body>
+{{- if .Heading}}
+<h1>{{.Heading}}</h1>
+{{- end}}
+{{- if .Generated}}
+<p class="meta">Generated {{.Generated}}</p>
+{{- end}}
+{{- range .Sections}}
+{{- if .Title}}
+<h2>{{.Title}}</h2>
+{{- end}}
+{{- if .Table}}
+<table>
+{{- if .Table.Headers}}
+<thead><tr>{{range .Table.Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+{{- end}}
+<tbody>
+{{- range .Table.Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- else if .Pre}}
+<pre>{{.Pre}}</pre>
+{{- else}}
+<p class="empty">{{.Empty}}</p>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// printHTML writes a complete, self-contained HTML page with inline styles
+// and no external resources.
+func printHTML(w io.Writer, v interface{}, opts printOptions) error {
+	doc, err := buildDocument(v, opts)
+	if err != nil {
+		return err
+	}
+	return htmlPage.Execute(w, doc)
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintMarkdown_List(t *testing.T) {
+	masks := []api.RelayAddress{
+		{ID: 1, FullAddress: "a_b@mozmail.com", Enabled: true, Description: "pipes | and\nnewlines"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatMarkdown, masks))
+	assert.Equal(t, strings.Join([]string{
+		"| ID | ADDRESS | ENABLED | DESCRIPTION | FORWARDED | BLOCKED | CREATED | LAST USED |",
+		"| --- | --- | --- | --- | --- | --- | --- | --- |",
+		`| 1 | a\_b@mozmail.com | true | pipes \| and newlines | 0 | 0 | - | - |`,
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestPrintMarkdown_SingleObjectAndEmptyList(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatMarkdown, &api.RealPhone{ID: 3, Number: "+15550001111"}))
+	assert.Contains(t, buf.String(), "| FIELD | VALUE |")
+	assert.Contains(t, buf.String(), "| Number | +15550001111 |")
+
+	buf.Reset()
+	require.NoError(t, Fprint(&buf, FormatMarkdown, []api.InboundContact{}))
+	assert.Equal(t, "_No inbound contacts found._\n", buf.String())
+}
+
+func TestPrintHTML_EscapesValues(t *testing.T) {
+	masks := []CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{ID: 1, Description: "<script>alert(1)</script>"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatHTML, masks))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.NotContains(t, out, "<script>")
+	assert.Contains(t, out, "&lt;script&gt;")
+	assert.Contains(t, out, "<th>ADDRESS</th>")
+}
+
+func TestPrintHTML_NoHeaders(t *testing.T) {
+	p, err := NewPrinter(FormatHTML, WithNoHeaders(true))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Fprint(&buf, []api.User{{Email: "me@example.com"}}))
+	assert.NotContains(t, buf.String(), "<thead>")
+	assert.Contains(t, buf.String(), "<td>me@example.com</td>")
+}
+
+func TestAccountReport(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	subdomain := "me"
+	export := AccountExport{
+		Masks: []CombinedMask{
+			{Type: "random", Mask: api.RelayAddress{ID: 1, FullAddress: "a@mozmail.com", Enabled: true}},
+			{Type: "random", Mask: api.RelayAddress{ID: 2, FullAddress: "b@mozmail.com"}},
+		},
+		Profiles: []api.Profile{{ID: 1, Subdomain: &subdomain, HasPremium: true, EmailsForwarded: 12}},
+		Contacts: []api.InboundContact{{ID: 1, Blocked: true}, {ID: 2}},
+		Users:    []api.User{{Email: "me@example.com"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatHTML, export))
+	out := buf.String()
+	for _, want := range []string{
+		"<h1>Firefox Relay account report</h1>",
+		"Generated 2024-06-01 12:00 UTC",
+		"<td>Random masks</td><td>2 (1 enabled, 1 disabled)</td>",
+		"<td>Inbound contacts</td><td>2 (1 blocked)</td>",
+		"<td>Emails forwarded</td><td>12</td>",
+		"<h2>Custom domain masks</h2>",
+		"No custom domain masks.",
+		"No phone masks.",
+		"<td>a@mozmail.com</td>",
+	} {
+		assert.Contains(t, out, want)
+	}
+	assert.NotContains(t, out, "src=", "the report must not load external resources")
+	assert.NotContains(t, out, "href=")
+
+	buf.Reset()
+	require.NoError(t, Fprint(&buf, FormatMarkdown, &export))
+	assert.Contains(t, buf.String(), "# Firefox Relay account report")
+	assert.Contains(t, buf.String(), "## Random masks")
+}
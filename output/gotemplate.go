@@ -0,0 +1,127 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+var templateFuncs = template.FuncMap{
+	"truncate": templateTruncate,
+	"date":     templateDate,
+	"join":     templateJoin,
+	"default":  templateDefault,
+	"json":     templateJSON,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+func parseGoTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid go-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func readTemplateFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template file: %w", err)
+	}
+	return string(data), nil
+}
+
+func printGoTemplate(w io.Writer, text string, v interface{}) error {
+	tmpl, err := parseGoTemplate(text)
+	if err != nil {
+		return err
+	}
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("error executing template: %w", err)
+	}
+	return nil
+}
+
+// templateTruncate shortens s to at most n characters, e.g.
+// {{.description | truncate 20}}.
+func templateTruncate(n int, v interface{}) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("truncate: length must not be negative, got %d", n)
+	}
+	s := fmt.Sprint(v)
+	if v == nil {
+		s = ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s, nil
+	}
+	if n <= 3 {
+		return string([]rune(s)[:n]), nil
+	}
+	return string([]rune(s)[:n-3]) + "...", nil
+}
+
+// templateDate reformats an RFC 3339 timestamp with a Go time layout, e.g.
+// {{.created_at | date "2006-01-02"}}. Null values render as an empty string.
+func templateDate(layout string, v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("date: expected timestamp string, got %T", v)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", fmt.Errorf("date: %w", err)
+	}
+	return t.Format(layout), nil
+}
+
+// templateJoin joins the elements of a list, e.g. {{.tags | join ", "}}.
+func templateJoin(sep string, v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return "", fmt.Errorf("join: expected list, got %T", v)
+	}
+	items := make([]string, rv.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(items, sep), nil
+}
+
+// templateDefault returns def when v is null, empty or false, e.g.
+// {{.last_used_at | default "never"}}.
+func templateDefault(def interface{}, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	if rv.IsZero() {
+		return def
+	}
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.Len() == 0 {
+		return def
+	}
+	return v
+}
+
+func templateJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintGoTemplate(t *testing.T) {
+	lastUsed := "2024-03-05T10:00:00Z"
+	masks := []CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{ID: 12345678, FullAddress: "abc@mozmail.com", Description: "Shopping accounts", CreatedAt: "2024-01-02T03:04:05Z", LastUsedAt: &lastUsed}},
+		{Type: "custom", Mask: &api.DomainAddress{ID: 2, FullAddress: "shop@me.mozmail.com"}},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		v        interface{}
+		want     string
+	}{
+		{
+			name:     "range over list",
+			template: `{{range .}}{{.mask.id}} {{.mask.full_address}}{{"\n"}}{{end}}`,
+			want:     "12345678 abc@mozmail.com\n2 shop@me.mozmail.com\n",
+		},
+		{
+			name:     "truncate",
+			template: `{{(index . 0).mask.description | truncate 8}}`,
+			want:     "Shopp...",
+		},
+		{
+			name:     "date",
+			template: `{{(index . 0).mask.created_at | date "2006-01-02"}}`,
+			want:     "2024-01-02",
+		},
+		{
+			name:     "default",
+			template: `{{range .}}{{.mask.last_used_at | default "never"}};{{end}}`,
+			want:     "2024-03-05T10:00:00Z;never;",
+		},
+		{
+			name:     "join",
+			template: `{{. | join ","}}`,
+			v:        []string{"a", "b", "c"},
+			want:     "a,b,c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.v
+			if v == nil {
+				v = masks
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, Fprint(&buf, FormatGoTemplate+"="+tt.template, v))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestPrintGoTemplate_NegativeTruncate(t *testing.T) {
+	var buf bytes.Buffer
+	err := Fprint(&buf, FormatGoTemplate+`={{"abc" | truncate -1}}`, []string{})
+	assert.ErrorContains(t, err, "truncate: length must not be negative, got -1")
+}
+
+func TestPrintGoTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mask.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("{{.full_address}} ({{.enabled}})\n"), 0o600))
+
+	var buf bytes.Buffer
+	format := FormatGoTemplateFile + "=" + path
+	require.NoError(t, ValidateFormat(format))
+	require.NoError(t, Fprint(&buf, format, &api.RelayAddress{FullAddress: "abc@mozmail.com", Enabled: true}))
+	assert.Equal(t, "abc@mozmail.com (true)\n", buf.String())
+}
+
+func TestValidateFormat(t *testing.T) {
+	valid := []string{
+		FormatText,
+		FormatJSONL,
+		"go-template={{.id}}",
+		"jsonpath={.id}",
+		"jsonpath=.id",
+		"jsonpath={range .[*]}{.id}{end}",
+	}
+	for _, format := range valid {
+		assert.NoError(t, ValidateFormat(format), format)
+	}
+
+	invalid := []string{
+		"xml",
+		"json=x",
+		"go-template",
+		"go-template=",
+		"go-template={{.id",
+		"go-template-file=/nonexistent/template",
+		"jsonpath={.id",
+		"jsonpath={range .[*]}{.id}",
+		"jsonpath={end}",
+		"jsonpath={.items[abc]}",
+	}
+	for _, format := range invalid {
+		assert.Error(t, ValidateFormat(format), format)
+	}
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// LineWriter writes one compact JSON object per line.
+type LineWriter struct {
+	enc      *json.Encoder
+	redactor *redactor
+}
+
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{enc: json.NewEncoder(w)}
+}
+
+func (lw *LineWriter) Write(v interface{}) error {
+	if lw.redactor != nil {
+		v = lw.redactor.redact(v)
+	}
+	if err := lw.enc.Encode(v); err != nil {
+		return fmt.Errorf("error formatting output: %v", err)
+	}
+	return nil
+}
+
+// printJSONLines writes each element of a list on its own line. Any other
+// value is written as a single line.
+func printJSONLines(w io.Writer, v interface{}) error {
+	lw := NewLineWriter(w)
+
+	rv := reflect.ValueOf(deref(v))
+	if rv.Kind() != reflect.Slice {
+		return lw.Write(v)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := lw.Write(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
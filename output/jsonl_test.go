@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintJSONLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		v         interface{}
+		wantLines int
+	}{
+		{
+			name:   "combined masks",
+			format: FormatJSONL,
+			v: []CombinedMask{
+				{Type: "random", Mask: api.RelayAddress{ID: 1, Description: "multi\nline"}},
+				{Type: "custom", Mask: api.DomainAddress{ID: 2}},
+			},
+			wantLines: 2,
+		},
+		{name: "contacts", format: FormatNDJSON, v: []api.InboundContact{{ID: 1}, {ID: 2}, {ID: 3}}, wantLines: 3},
+		{name: "empty list", format: FormatJSONL, v: []api.RelayNumber{}, wantLines: 0},
+		{name: "single object", format: FormatJSONL, v: &api.RelayAddress{ID: 1}, wantLines: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Fprint(&buf, tt.format, tt.v))
+
+			out := strings.TrimSuffix(buf.String(), "\n")
+			if tt.wantLines == 0 {
+				assert.Empty(t, out)
+				return
+			}
+
+			lines := strings.Split(out, "\n")
+			require.Len(t, lines, tt.wantLines)
+			for _, line := range lines {
+				var obj map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(line), &obj), line)
+			}
+		})
+	}
+}
@@ -0,0 +1,631 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a kubectl-style JSONPath template: literal text interleaved
+// with {expressions}. Supported expressions are paths such as {.mask.id},
+// {range <path>}...{end} and string literals such as {"\n"}.
+//
+// Paths support child access (.name or ['name']), recursive descent (..name),
+// wildcards (.* or [*]), indexes ([0], [-1]), slices ([1:3]) and filters
+// ([?(@.enabled==true)]). A path starting with $ is evaluated against the
+// root document; any other path is relative to the current {range} item.
+type jsonPath struct {
+	root jpRange
+}
+
+type jpNode interface{}
+
+type jpText string
+
+type jpField struct {
+	path jpPath
+}
+
+type jpRange struct {
+	path jpPath
+	body []jpNode
+}
+
+type jpPath struct {
+	fromRoot bool
+	steps    []jpStep
+}
+
+type jpStepKind int
+
+const (
+	jpChild jpStepKind = iota
+	jpRecursive
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpStep struct {
+	kind   jpStepKind
+	name   string
+	index  int
+	start  *int
+	end    *int
+	filter *jpFilterExpr
+}
+
+// jpFilterExpr is the body of [?(...)]: either an existence test such as
+// @.description, or a comparison of a relative path with a literal.
+type jpFilterExpr struct {
+	left  jpPath
+	op    string
+	right interface{}
+}
+
+var jpOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseJSONPath(text string) (*jsonPath, error) {
+	if !strings.Contains(text, "{") {
+		if strings.Contains(text, "}") {
+			return nil, strayBraceError(text)
+		}
+		text = "{" + text + "}"
+	}
+
+	p := &jsonPath{}
+	stack := []*jpRange{&p.root}
+	add := func(n jpNode) {
+		top := stack[len(stack)-1]
+		top.body = append(top.body, n)
+	}
+
+	addText := func(t string) error {
+		if strings.Contains(t, "}") {
+			return strayBraceError(text)
+		}
+		add(jpText(t))
+		return nil
+	}
+
+	for i := 0; i < len(text); {
+		open := strings.IndexByte(text[i:], '{')
+		if open < 0 {
+			if err := addText(text[i:]); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if open > 0 {
+			if err := addText(text[i : i+open]); err != nil {
+				return nil, err
+			}
+		}
+		start := i + open
+		end, err := matchingBracket(text, start, '{', '}')
+		if err != nil {
+			return nil, err
+		}
+		expr := strings.TrimSpace(text[start+1 : end])
+		i = end + 1
+
+		switch {
+		case expr == "":
+			return nil, fmt.Errorf("invalid jsonpath: empty expression {}")
+		case expr == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("invalid jsonpath: {end} without {range}")
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(expr, "range "):
+			path, err := parsePath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			r := &jpRange{path: path}
+			add(r)
+			stack = append(stack, r)
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			s, err := unquoteJSONPath(expr)
+			if err != nil {
+				return nil, err
+			}
+			add(jpText(s))
+		default:
+			path, err := parsePath(expr)
+			if err != nil {
+				return nil, err
+			}
+			add(&jpField{path: path})
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("invalid jsonpath: {range} without {end}")
+	}
+	return p, nil
+}
+
+func strayBraceError(text string) error {
+	return fmt.Errorf("invalid jsonpath: unexpected \"}\" outside an expression in %q", text)
+}
+
+// matchingBracket returns the index of the bracket closing the one at
+// text[start], skipping over quoted strings.
+func matchingBracket(text string, start int, open, close byte) (int, error) {
+	depth := 0
+	var quote byte
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid jsonpath: unclosed %q in %q", string(open), text[start:])
+}
+
+func unquoteJSONPath(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid jsonpath: bad string literal %s", s)
+	}
+	return unquoted, nil
+}
+
+func parsePath(expr string) (jpPath, error) {
+	var path jpPath
+	s := expr
+	switch {
+	case strings.HasPrefix(s, "$"):
+		path.fromRoot = true
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		s = s[1:]
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			if strings.HasPrefix(s[i:], "..") {
+				i += 2
+				name := readPathName(s, &i)
+				if name == "" {
+					return path, fmt.Errorf("invalid jsonpath %q: expected field name after ..", expr)
+				}
+				path.steps = append(path.steps, jpStep{kind: jpRecursive, name: name})
+				continue
+			}
+			i++
+			if i < len(s) && s[i] == '*' {
+				path.steps = append(path.steps, jpStep{kind: jpWildcard})
+				i++
+				continue
+			}
+			if name := readPathName(s, &i); name != "" {
+				path.steps = append(path.steps, jpStep{kind: jpChild, name: name})
+			}
+		case '[':
+			end, err := matchingBracket(s, i, '[', ']')
+			if err != nil {
+				return path, err
+			}
+			step, err := parseBracket(strings.TrimSpace(s[i+1 : end]))
+			if err != nil {
+				return path, fmt.Errorf("invalid jsonpath %q: %w", expr, err)
+			}
+			path.steps = append(path.steps, step)
+			i = end + 1
+		default:
+			return path, fmt.Errorf("invalid jsonpath %q: unexpected %q", expr, string(s[i]))
+		}
+	}
+	return path, nil
+}
+
+func readPathName(s string, i *int) string {
+	start := *i
+	for *i < len(s) && s[*i] != '.' && s[*i] != '[' {
+		*i++
+	}
+	return s[start:*i]
+}
+
+func parseBracket(inner string) (jpStep, error) {
+	switch {
+	case inner == "*":
+		return jpStep{kind: jpWildcard}, nil
+	case strings.HasPrefix(inner, "?(") && strings.HasSuffix(inner, ")"):
+		filter, err := parseFilter(strings.TrimSpace(inner[2 : len(inner)-1]))
+		if err != nil {
+			return jpStep{}, err
+		}
+		return jpStep{kind: jpFilter, filter: filter}, nil
+	case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+		name, err := unquoteJSONPath(inner)
+		if err != nil {
+			return jpStep{}, err
+		}
+		return jpStep{kind: jpChild, name: name}, nil
+	case strings.Contains(inner, ":"):
+		startText, endText, _ := strings.Cut(inner, ":")
+		if strings.Contains(endText, ":") {
+			return jpStep{}, fmt.Errorf("slice steps are not supported in %q", inner)
+		}
+		step := jpStep{kind: jpSlice}
+		if startText = strings.TrimSpace(startText); startText != "" {
+			n, err := strconv.Atoi(startText)
+			if err != nil {
+				return jpStep{}, fmt.Errorf("bad slice start %q", startText)
+			}
+			step.start = &n
+		}
+		if endText = strings.TrimSpace(endText); endText != "" {
+			n, err := strconv.Atoi(endText)
+			if err != nil {
+				return jpStep{}, fmt.Errorf("bad slice end %q", endText)
+			}
+			step.end = &n
+		}
+		return step, nil
+	}
+
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return jpStep{}, fmt.Errorf("bad index %q", inner)
+	}
+	return jpStep{kind: jpIndex, index: n}, nil
+}
+
+func parseFilter(expr string) (*jpFilterExpr, error) {
+	if hasLogicalOperator(expr) {
+		return nil, fmt.Errorf("filter %q: && and || are not supported", expr)
+	}
+	leftText, op, rightText := splitFilter(expr)
+	if !strings.HasPrefix(leftText, "@") {
+		return nil, fmt.Errorf("filter %q must start with @", expr)
+	}
+	left, err := parsePath(leftText)
+	if err != nil {
+		return nil, err
+	}
+	filter := &jpFilterExpr{left: left, op: op}
+	if op == "" {
+		return filter, nil
+	}
+
+	switch {
+	case rightText == "true":
+		filter.right = true
+	case rightText == "false":
+		filter.right = false
+	case rightText == "null":
+		filter.right = nil
+	case strings.HasPrefix(rightText, "'") || strings.HasPrefix(rightText, `"`):
+		s, err := unquoteJSONPath(rightText)
+		if err != nil {
+			return nil, err
+		}
+		filter.right = s
+	default:
+		if n, err := strconv.Atoi(rightText); err == nil {
+			filter.right = n
+		} else if f, err := strconv.ParseFloat(rightText, 64); err == nil {
+			filter.right = f
+		} else {
+			return nil, fmt.Errorf("bad filter value %q", rightText)
+		}
+	}
+	return filter, nil
+}
+
+// splitFilter splits a filter on its first comparison operator outside of
+// quotes. op is empty for existence tests.
+func splitFilter(expr string) (left, op, right string) {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			continue
+		}
+		for _, candidate := range jpOperators {
+			if strings.HasPrefix(expr[i:], candidate) {
+				return strings.TrimSpace(expr[:i]), candidate, strings.TrimSpace(expr[i+len(candidate):])
+			}
+		}
+	}
+	return expr, "", ""
+}
+
+// hasLogicalOperator reports whether expr has && or || outside of quotes.
+func hasLogicalOperator(expr string) bool {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||"):
+			return true
+		}
+	}
+	return false
+}
+
+func printJSONPath(w io.Writer, text string, v interface{}) error {
+	p, err := parseJSONPath(text)
+	if err != nil {
+		return err
+	}
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	return p.execute(w, data)
+}
+
+func (p *jsonPath) execute(w io.Writer, data interface{}) error {
+	return executeJSONPath(w, p.root.body, data, data)
+}
+
+func executeJSONPath(w io.Writer, nodes []jpNode, root, current interface{}) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case jpText:
+			if _, err := io.WriteString(w, string(n)); err != nil {
+				return err
+			}
+		case *jpField:
+			values := n.path.eval(root, current)
+			parts := make([]string, len(values))
+			for i, value := range values {
+				parts[i] = formatJSONPathValue(value)
+			}
+			if _, err := io.WriteString(w, strings.Join(parts, " ")); err != nil {
+				return err
+			}
+		case *jpRange:
+			values := n.path.eval(root, current)
+			// {range .} over a single list iterates its items.
+			if len(values) == 1 {
+				if items, ok := values[0].([]interface{}); ok {
+					values = items
+				}
+			}
+			for _, value := range values {
+				if err := executeJSONPath(w, n.body, root, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p jpPath) eval(root, current interface{}) []interface{} {
+	start := current
+	if p.fromRoot {
+		start = root
+	}
+	values := []interface{}{start}
+	for _, step := range p.steps {
+		var next []interface{}
+		for _, value := range values {
+			next = step.apply(next, root, value)
+		}
+		values = next
+	}
+	return values
+}
+
+func (s jpStep) apply(out []interface{}, root, value interface{}) []interface{} {
+	switch s.kind {
+	case jpChild:
+		if obj, ok := value.(map[string]interface{}); ok {
+			if child, ok := obj[s.name]; ok {
+				out = append(out, child)
+			}
+		}
+	case jpRecursive:
+		out = collectRecursive(out, s.name, value)
+	case jpWildcard:
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for _, key := range sortedKeys(v) {
+				out = append(out, v[key])
+			}
+		case []interface{}:
+			out = append(out, v...)
+		}
+	case jpIndex:
+		if items, ok := value.([]interface{}); ok {
+			i := s.index
+			if i < 0 {
+				i += len(items)
+			}
+			if i >= 0 && i < len(items) {
+				out = append(out, items[i])
+			}
+		}
+	case jpSlice:
+		if items, ok := value.([]interface{}); ok {
+			start, end := sliceBound(s.start, 0, len(items)), sliceBound(s.end, len(items), len(items))
+			if start < end {
+				out = append(out, items[start:end]...)
+			}
+		}
+	case jpFilter:
+		if items, ok := value.([]interface{}); ok {
+			for _, item := range items {
+				if s.filter.match(root, item) {
+					out = append(out, item)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func sliceBound(bound *int, def, length int) int {
+	if bound == nil {
+		return def
+	}
+	n := *bound
+	if n < 0 {
+		n += length
+	}
+	return max(0, min(n, length))
+}
+
+func collectRecursive(out []interface{}, name string, value interface{}) []interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if child, ok := v[name]; ok {
+			out = append(out, child)
+		}
+		for _, key := range sortedKeys(v) {
+			out = collectRecursive(out, name, v[key])
+		}
+	case []interface{}:
+		for _, item := range v {
+			out = collectRecursive(out, name, item)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *jpFilterExpr) match(root, item interface{}) bool {
+	values := f.left.eval(root, item)
+	if f.op == "" {
+		return len(values) > 0 && values[0] != nil
+	}
+	if len(values) == 0 {
+		return false
+	}
+
+	left := values[0]
+	switch f.op {
+	case "==":
+		return jsonPathEqual(left, f.right)
+	case "!=":
+		return !jsonPathEqual(left, f.right)
+	}
+
+	cmp, ok := jsonPathCompare(left, f.right)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func jsonPathEqual(a, b interface{}) bool {
+	if cmp, ok := jsonPathCompare(a, b); ok {
+		return cmp == 0
+	}
+	return a == b
+}
+
+// jsonPathCompare orders two numbers or two strings. It reports false for
+// any other pair of values.
+func jsonPathCompare(a, b interface{}) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+	x, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+	y, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func formatJSONPathValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
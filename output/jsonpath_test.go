@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintJSONPath(t *testing.T) {
+	masks := []api.RelayAddress{
+		{ID: 1, FullAddress: "a@mozmail.com", Enabled: true, NumForwarded: 10, Description: "news"},
+		{ID: 2, FullAddress: "b@mozmail.com", Enabled: false, NumForwarded: 3},
+		{ID: 3, FullAddress: "c@mozmail.com", Enabled: true, NumForwarded: 0, Description: "shop"},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{name: "wildcard", expr: "{[*].id}", want: "1 2 3"},
+		{name: "without braces", expr: "[*].full_address", want: "a@mozmail.com b@mozmail.com c@mozmail.com"},
+		{name: "index", expr: "{[0].full_address}", want: "a@mozmail.com"},
+		{name: "negative index", expr: "{[-1].id}", want: "3"},
+		{name: "slice", expr: "{[1:].id}", want: "2 3"},
+		{name: "bracket child", expr: "{[0]['full_address']}", want: "a@mozmail.com"},
+		{name: "recursive descent", expr: "{..id}", want: "1 2 3"},
+		{name: "filter bool", expr: "{[?(@.enabled==true)].id}", want: "1 3"},
+		{name: "filter number", expr: "{[?(@.num_forwarded > 2)].id}", want: "1 2"},
+		{name: "filter string", expr: `{[?(@.description=="shop")].full_address}`, want: "c@mozmail.com"},
+		{name: "filter not equal", expr: "{[?(@.description!='')].id}", want: "1 3"},
+		{
+			name: "range",
+			expr: `{range .[*]}{.id}{"\t"}{.full_address}{"\n"}{end}`,
+			want: "1\ta@mozmail.com\n2\tb@mozmail.com\n3\tc@mozmail.com\n",
+		},
+		{name: "range over list value", expr: `{range .}{.id},{end}`, want: "1,2,3,"},
+		{name: "root inside range", expr: `{range .[0:1]}{$[2].id}{end}`, want: "3"},
+		{name: "object value", expr: "{[0:1]}", want: `{"address":"","block_list_emails":false,"created_at":"","description":"news","domain":0,"enabled":true,"full_address":"a@mozmail.com","generated_for":"","id":1,"last_used_at":null,"num_blocked":0,"num_forwarded":10,"num_replied":0,"num_spam":0,"used_on":""}`},
+		{name: "missing field", expr: "{[0].nope}", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Fprint(&buf, FormatJSONPath+"="+tt.expr, masks))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestPrintJSONPath_NestedMask(t *testing.T) {
+	masks := []CombinedMask{
+		{Type: "random", Mask: &api.RelayAddress{ID: 1, FullAddress: "a@mozmail.com"}},
+		{Type: "custom", Mask: api.DomainAddress{ID: 2, FullAddress: "shop@me.mozmail.com"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, `jsonpath={range .[?(@.type=="custom")]}{.mask.full_address}{end}`, masks))
+	assert.Equal(t, "shop@me.mozmail.com", buf.String())
+}
+
+func TestPrintJSONPath_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{"unclosed brace", "{.id", `unclosed "{"`},
+		{"unclosed bracket", "{[0.id}", `unclosed "["`},
+		{"stray closing brace", "}", `unexpected "}" outside an expression`},
+		{"stray closing brace after expression", "{.id}}", `unexpected "}" outside an expression`},
+		{"empty expression", "{}", "empty expression"},
+		{"end without range", "{.id}{end}", "{end} without {range}"},
+		{"range without end", "{range .[*]}{.id}", "{range} without {end}"},
+		{"slice step", "{[::-1].id}", `slice steps are not supported in "::-1"`},
+		{"zero slice step", "{[0:0:0].id}", `slice steps are not supported in "0:0:0"`},
+		{"bad slice start", "{[a:].id}", `bad slice start "a"`},
+		{"bad index", "{[x].id}", `bad index "x"`},
+		{"and in filter", "{[?(@.enabled==true && @.id>1)].id}", "&& and || are not supported"},
+		{"or in filter", "{[?(@.id==1 || @.id==2)].id}", "&& and || are not supported"},
+		{"filter without @", "{[?(id==1)].id}", "must start with @"},
+		{"bad filter value", "{[?(@.id==one)].id}", `bad filter value "one"`},
+		{"bad string literal", `{"abc}`, "unclosed"},
+		{"missing field after recursive descent", "{..}", "expected field name after .."},
+		{"path without dot", "{id}", `unexpected "i"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Fprint(&buf, FormatJSONPath+"="+tt.expr, []api.RelayAddress{{ID: 1}})
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Empty(t, buf.String())
+		})
+	}
+
+	var buf bytes.Buffer
+	err := Fprint(&buf, FormatJSONPath+"=[?(@.description=='a && b')].id", []api.RelayAddress{{ID: 1, Description: "a && b"}})
+	require.NoError(t, err, "operators inside quotes are literal text")
+	assert.Equal(t, "1", buf.String())
+}
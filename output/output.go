@@ -12,275 +12,212 @@ import (
 )
 
 const (
-	FormatText = "text"
-	FormatJSON = "json"
+	FormatText     = "text"
+	FormatWide     = "wide"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatJSONL    = "jsonl"
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+
+	FormatGoTemplate     = "go-template"
+	FormatGoTemplateFile = "go-template-file"
+	FormatJSONPath       = "jsonpath"
 )
 
+// templateFormats take an argument after "=", e.g. "jsonpath={.id}".
+var templateFormats = []string{FormatGoTemplate, FormatGoTemplateFile, FormatJSONPath}
+
 type CombinedMask struct {
 	Type string      `json:"type"`
 	Mask interface{} `json:"mask"`
 }
 
-func ValidFormats() []string {
-	return []string{FormatText, FormatJSON}
+type ContextInfo struct {
+	Current   bool   `json:"current"`
+	Name      string `json:"name"`
+	BaseURL   string `json:"base_url"`
+	Output    string `json:"output"`
+	Timeout   string `json:"timeout"`
+	KeySource string `json:"key_source"`
 }
 
-func IsValidFormat(format string) bool {
-	for _, f := range ValidFormats() {
-		if f == format {
-			return true
-		}
-	}
-	return false
+type AuthStatus struct {
+	Context     string `json:"context"`
+	Email       string `json:"email"`
+	HasPremium  bool   `json:"has_premium"`
+	KeySource   string `json:"key_source"`
+	Fingerprint string `json:"fingerprint"`
 }
 
-func Print(format string, v interface{}) error {
-	return Fprint(os.Stdout, format, v)
+// BulkResult is the outcome of a bulk command for one mask. Status is
+// "updated", "deleted", "failed", or "skipped" if the command was
+// interrupted first.
+type BulkResult struct {
+	Type        string  `json:"type"`
+	ID          int     `json:"id"`
+	FullAddress string  `json:"full_address"`
+	Status      string  `json:"status"`
+	Error       *string `json:"error"`
 }
 
-func Fprint(w io.Writer, format string, v interface{}) error {
-	switch format {
-	case FormatJSON:
-		return printJSON(w, v)
-	case FormatText:
-		return printText(w, v)
-	default:
-		return fmt.Errorf("unsupported output format: %s", format)
-	}
+// BatchResult is the outcome of one operation of the batch command. Line
+// is the operation's line in the input, ID the ID of the item it created or
+// changed and Result the item as returned by the API. Status is
+// "succeeded", "failed", or "skipped" if the command was interrupted first.
+type BatchResult struct {
+	Line   int         `json:"line"`
+	Op     string      `json:"op"`
+	Status string      `json:"status"`
+	ID     *int        `json:"id"`
+	Result interface{} `json:"result"`
+	Error  *string     `json:"error"`
 }
 
-func printJSON(w io.Writer, v interface{}) error {
-	data, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error formatting output: %v", err)
-	}
-	fmt.Fprintln(w, string(data))
-	return nil
+func ValidFormats() []string {
+	return []string{FormatText, FormatWide, FormatJSON, FormatJSONL, FormatNDJSON, FormatYAML, FormatCSV, FormatTSV, FormatMarkdown, FormatHTML}
 }
 
-func printText(w io.Writer, v interface{}) error {
-	switch data := v.(type) {
-	case []api.User:
-		return printUsers(w, data)
-	case []api.Profile:
-		return printProfiles(w, data)
-	case api.Profile:
-		return printProfiles(w, []api.Profile{data})
-	case []CombinedMask:
-		return printCombinedMasks(w, data)
-	case []api.RelayAddress:
-		return printRelayAddresses(w, data)
-	case api.RelayAddress:
-		return printRelayAddresses(w, []api.RelayAddress{data})
-	case []api.DomainAddress:
-		return printDomainAddresses(w, data)
-	case api.DomainAddress:
-		return printDomainAddresses(w, []api.DomainAddress{data})
-	case []api.RelayNumber:
-		return printRelayNumbers(w, data)
-	case api.RelayNumber:
-		return printRelayNumbers(w, []api.RelayNumber{data})
-	case []api.InboundContact:
-		return printInboundContacts(w, data)
-	case api.InboundContact:
-		return printInboundContacts(w, []api.InboundContact{data})
-	case []api.RealPhone:
-		return printRealPhones(w, data)
-	case api.RealPhone:
-		return printRealPhones(w, []api.RealPhone{data})
-	case api.RelayNumberSuggestions:
-		return printRelayNumberSuggestions(w, data)
-	case []api.PhoneNumberOption:
-		return printPhoneNumberOptions(w, data)
-	default:
-		return printJSON(w, v)
-	}
+func IsValidFormat(format string) bool {
+	return ValidateFormat(format) == nil
 }
 
-func printUsers(w io.Writer, users []api.User) error {
-	if len(users) == 0 {
-		fmt.Fprintln(w, "No users found.")
-		return nil
+// ValidateFormat checks that format names a known output format. Template
+// formats are also parsed so that syntax errors are reported before any
+// API request is made.
+func ValidateFormat(format string) error {
+	name, arg, hasArg := strings.Cut(format, "=")
+	if !isTemplateFormat(name) {
+		if !hasArg {
+			for _, f := range ValidFormats() {
+				if f == format {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("invalid output format %q: must be one of [%s]", format, FormatList())
 	}
 
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "EMAIL")
-	for _, u := range users {
-		fmt.Fprintln(tw, u.Email)
+	if arg == "" {
+		return fmt.Errorf("output format %s requires an argument, e.g. -o %s=...", name, name)
 	}
-	return tw.Flush()
-}
-
-func printProfiles(w io.Writer, profiles []api.Profile) error {
-	if len(profiles) == 0 {
-		fmt.Fprintln(w, "No profiles found.")
-		return nil
-	}
-
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tSUBDOMAIN\tPREMIUM\tPHONE\tFORWARDED\tBLOCKED\tREPLIED")
-	for _, p := range profiles {
-		subdomain := "-"
-		if p.Subdomain != nil {
-			subdomain = *p.Subdomain
+	switch name {
+	case FormatGoTemplate:
+		_, err := parseGoTemplate(arg)
+		return err
+	case FormatGoTemplateFile:
+		text, err := readTemplateFile(arg)
+		if err != nil {
+			return err
 		}
-		fmt.Fprintf(tw, "%d\t%s\t%t\t%t\t%d\t%d\t%d\n",
-			p.ID,
-			subdomain,
-			p.HasPremium,
-			p.HasPhone,
-			p.EmailsForwarded,
-			p.EmailsBlocked,
-			p.EmailsReplied,
-		)
+		_, err = parseGoTemplate(text)
+		return err
+	default:
+		_, err := parseJSONPath(arg)
+		return err
 	}
-	return tw.Flush()
 }
 
-func printCombinedMasks(w io.Writer, masks []CombinedMask) error {
-	if len(masks) == 0 {
-		fmt.Fprintln(w, "No masks found.")
-		return nil
-	}
-
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tTYPE\tADDRESS\tENABLED\tDESCRIPTION\tFORWARDED\tBLOCKED")
-	for _, m := range masks {
-		switch mask := m.Mask.(type) {
-		case api.RelayAddress:
-			desc := truncate(mask.Description, 30)
-			fmt.Fprintf(tw, "%d\t%s\t%s\t%t\t%s\t%d\t%d\n",
-				mask.ID,
-				m.Type,
-				mask.FullAddress,
-				mask.Enabled,
-				desc,
-				mask.NumForwarded,
-				mask.NumBlocked,
-			)
-		case api.DomainAddress:
-			desc := truncate(mask.Description, 30)
-			fmt.Fprintf(tw, "%d\t%s\t%s\t%t\t%s\t%d\t%d\n",
-				mask.ID,
-				m.Type,
-				mask.FullAddress,
-				mask.Enabled,
-				desc,
-				mask.NumForwarded,
-				mask.NumBlocked,
-			)
+func isTemplateFormat(name string) bool {
+	for _, f := range templateFormats {
+		if f == name {
+			return true
 		}
 	}
-	return tw.Flush()
+	return false
 }
 
-func printRelayAddresses(w io.Writer, addresses []api.RelayAddress) error {
-	if len(addresses) == 0 {
-		fmt.Fprintln(w, "No random masks found.")
-		return nil
-	}
-
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tADDRESS\tENABLED\tDESCRIPTION\tFORWARDED\tBLOCKED")
-	for _, a := range addresses {
-		desc := truncate(a.Description, 30)
-		fmt.Fprintf(tw, "%d\t%s\t%t\t%s\t%d\t%d\n",
-			a.ID,
-			a.FullAddress,
-			a.Enabled,
-			desc,
-			a.NumForwarded,
-			a.NumBlocked,
-		)
+// FormatList returns the valid formats for use in help and error messages,
+// e.g. "text|json|yaml".
+func FormatList() string {
+	formats := ValidFormats()
+	for _, f := range templateFormats {
+		formats = append(formats, f+"=...")
 	}
-	return tw.Flush()
+	return strings.Join(formats, "|")
 }
 
-func printDomainAddresses(w io.Writer, addresses []api.DomainAddress) error {
-	if len(addresses) == 0 {
-		fmt.Fprintln(w, "No custom domain masks found.")
-		return nil
+// IsTableFormat reports whether format prints lists as tables, so that
+// columns can be chosen.
+func IsTableFormat(format string) bool {
+	switch format {
+	case FormatText, FormatWide, FormatCSV, FormatTSV, FormatMarkdown, FormatHTML:
+		return true
 	}
+	return false
+}
 
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tADDRESS\tENABLED\tDESCRIPTION\tFORWARDED\tBLOCKED")
-	for _, a := range addresses {
-		desc := truncate(a.Description, 30)
-		fmt.Fprintf(tw, "%d\t%s\t%t\t%s\t%d\t%d\n",
-			a.ID,
-			a.FullAddress,
-			a.Enabled,
-			desc,
-			a.NumForwarded,
-			a.NumBlocked,
-		)
-	}
-	return tw.Flush()
+// IsLineFormat reports whether format writes one JSON object per line.
+func IsLineFormat(format string) bool {
+	return format == FormatJSONL || format == FormatNDJSON
 }
 
-func printRelayNumbers(w io.Writer, numbers []api.RelayNumber) error {
-	if len(numbers) == 0 {
-		fmt.Fprintln(w, "No phone masks found.")
-		return nil
-	}
+func Print(format string, v interface{}) error {
+	return Fprint(os.Stdout, format, v)
+}
 
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tNUMBER\tENABLED\tLOCATION\tTEXTS LEFT\tMINS LEFT")
-	for _, n := range numbers {
-		fmt.Fprintf(tw, "%d\t%s\t%t\t%s\t%d\t%d\n",
-			n.ID,
-			n.Number,
-			n.Enabled,
-			n.Location,
-			n.RemainingText,
-			n.RemainingMin,
-		)
-	}
-	return tw.Flush()
+func Fprint(w io.Writer, format string, v interface{}) error {
+	return fprint(w, format, v, printOptions{width: terminalWidth(w)})
 }
 
-func printInboundContacts(w io.Writer, contacts []api.InboundContact) error {
-	if len(contacts) == 0 {
-		fmt.Fprintln(w, "No inbound contacts found.")
-		return nil
+func fprint(w io.Writer, format string, v interface{}, opts printOptions) error {
+	if name, arg, ok := strings.Cut(format, "="); ok && isTemplateFormat(name) {
+		return printTemplate(w, name, arg, v)
 	}
 
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tNUMBER\tBLOCKED\tCALLS\tTEXTS\tLAST CONTACT")
-	for _, c := range contacts {
-		lastDate := c.LastInboundDate
-		fmt.Fprintf(tw, "%d\t%s\t%t\t%d\t%d\t%s\n",
-			c.ID,
-			c.InboundNumber,
-			c.Blocked,
-			c.NumCalls,
-			c.NumTexts,
-			lastDate,
-		)
+	switch format {
+	case FormatJSON:
+		return printJSON(w, v)
+	case FormatJSONL, FormatNDJSON:
+		return printJSONLines(w, v)
+	case FormatYAML:
+		return printYAML(w, v)
+	case FormatCSV:
+		return printDelimited(w, format, ',', v, opts)
+	case FormatTSV:
+		return printDelimited(w, format, '\t', v, opts)
+	case FormatMarkdown:
+		return printMarkdown(w, v, opts)
+	case FormatHTML:
+		return printHTML(w, v, opts)
+	case FormatText:
+		return printText(w, v, opts)
+	case FormatWide:
+		opts.wide = true
+		return printText(w, v, opts)
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
 	}
-	return tw.Flush()
 }
 
-func printRealPhones(w io.Writer, phones []api.RealPhone) error {
-	if len(phones) == 0 {
-		fmt.Fprintln(w, "No forwarding numbers found.")
-		return nil
+func printTemplate(w io.Writer, name, arg string, v interface{}) error {
+	switch name {
+	case FormatGoTemplate:
+		return printGoTemplate(w, arg, v)
+	case FormatGoTemplateFile:
+		text, err := readTemplateFile(arg)
+		if err != nil {
+			return err
+		}
+		return printGoTemplate(w, text, v)
+	default:
+		return printJSONPath(w, arg, v)
 	}
+}
 
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "ID\tNUMBER\tVERIFIED\tCOUNTRY")
-	for _, p := range phones {
-		fmt.Fprintf(tw, "%d\t%s\t%t\t%s\n",
-			p.ID,
-			p.Number,
-			p.Verified,
-			p.CountryCode,
-		)
+func printJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting output: %v", err)
 	}
-	return tw.Flush()
+	fmt.Fprintln(w, string(data))
+	return nil
 }
 
-func printRelayNumberSuggestions(w io.Writer, suggestions api.RelayNumberSuggestions) error {
+func printRelayNumberSuggestions(w io.Writer, suggestions api.RelayNumberSuggestions, _ printOptions) error {
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 
 	if suggestions.RealNum != nil {
@@ -314,34 +251,9 @@ func printRelayNumberSuggestions(w io.Writer, suggestions api.RelayNumberSuggest
 	return tw.Flush()
 }
 
-func printPhoneNumberOptions(w io.Writer, options []api.PhoneNumberOption) error {
-	if len(options) == 0 {
-		fmt.Fprintln(w, "No phone numbers found.")
-		return nil
-	}
-
-	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(tw, "NUMBER\tLOCATION\tREGION\tCOUNTRY")
-	for _, opt := range options {
-		locality := "-"
-		if opt.Locality != nil {
-			locality = *opt.Locality
-		}
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-			opt.PhoneNumber,
-			locality,
-			opt.Region,
-			opt.ISOCountry,
-		)
-	}
-	return tw.Flush()
-}
-
-func truncate(s string, maxLen int) string {
-	s = strings.ReplaceAll(s, "\n", " ")
-	s = strings.ReplaceAll(s, "\t", " ")
-	if len(s) <= maxLen {
-		return s
+func orDash(s string) string {
+	if s == "" {
+		return "-"
 	}
-	return s[:maxLen-3] + "..."
+	return s
 }
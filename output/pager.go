@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/term"
+)
+
+// page writes data to out, through $PAGER (or less, if installed) when it
+// has more lines than the terminal. The output is written directly if no
+// pager can be started.
+func page(out *os.File, data []byte) error {
+	_, height, err := term.GetSize(int(out.Fd()))
+	if err != nil || bytes.Count(data, []byte("\n")) < height {
+		_, err := out.Write(data)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err != nil {
+			_, err := out.Write(data)
+			return err
+		}
+		pager = "less"
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", pager)
+	} else {
+		cmd = exec.Command("sh", "-c", pager)
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// Quit if the output fits, keep colors, and leave the text on screen.
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+
+	if err := cmd.Start(); err != nil {
+		_, err := out.Write(data)
+		return err
+	}
+	// The pager's exit status is not meaningful to the command, e.g. when
+	// the user quits before reading all output.
+	_ = cmd.Wait()
+	return nil
+}
@@ -0,0 +1,321 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Printer writes command results in a configured output format. It is
+// built once from the root flags so that options such as --query apply to
+// every command.
+type Printer struct {
+	format    string
+	query     string
+	code      *queryCode
+	colorMode string
+	pager     bool
+	timezone  string
+	redactor  *redactor
+	version   string
+	opts      printOptions
+}
+
+// printOptions control how tables are printed. width is the terminal width,
+// or 0 if unknown.
+type printOptions struct {
+	columns   []string
+	sortBy    string
+	noHeaders bool
+	wide      bool
+	width     int
+	color     bool
+
+	absoluteTimes bool
+	location      *time.Location
+}
+
+type PrinterOption func(*Printer)
+
+// OptionError reports a printer option that does not fit the printed
+// value, such as an unknown --sort-by or --columns field. Such options are
+// checked against the types a command prints with Check, and again when
+// printing.
+type OptionError struct {
+	Err error
+}
+
+func (e *OptionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// WithQuery applies a jq expression to the JSON representation of each
+// value before it is printed.
+func WithQuery(query string) PrinterOption {
+	return func(p *Printer) {
+		p.query = query
+	}
+}
+
+// WithColumns selects the table columns, by JSON field name, for table
+// formats.
+func WithColumns(columns []string) PrinterOption {
+	return func(p *Printer) {
+		p.opts.columns = columns
+	}
+}
+
+// WithSortBy orders lists by a JSON field name, descending if the name is
+// prefixed with "-".
+func WithSortBy(field string) PrinterOption {
+	return func(p *Printer) {
+		p.opts.sortBy = field
+	}
+}
+
+// WithNoHeaders omits the header row from tables.
+func WithNoHeaders(noHeaders bool) PrinterOption {
+	return func(p *Printer) {
+		p.opts.noHeaders = noHeaders
+	}
+}
+
+// WithColor sets the color mode: auto, always or never.
+func WithColor(mode string) PrinterOption {
+	return func(p *Printer) {
+		p.colorMode = mode
+	}
+}
+
+// WithPager pages long text output printed to a terminal.
+func WithPager(enabled bool) PrinterOption {
+	return func(p *Printer) {
+		p.pager = enabled
+	}
+}
+
+// WithTimezone shows absolute times in the named IANA time zone, such as
+// "Europe/Berlin" or "UTC". The default is the local time zone.
+func WithTimezone(name string) PrinterOption {
+	return func(p *Printer) {
+		p.timezone = name
+	}
+}
+
+// WithAbsoluteTimes shows timestamps in text output as dates and times
+// rather than relative to now.
+func WithAbsoluteTimes(absolute bool) PrinterOption {
+	return func(p *Printer) {
+		p.opts.absoluteTimes = absolute
+	}
+}
+
+// WithRedaction replaces email addresses, phone numbers and the profile
+// subdomain with stable pseudonyms derived from key, so output can be shared
+// without exposing them. A nil key disables redaction.
+func WithRedaction(key []byte) PrinterOption {
+	return func(p *Printer) {
+		if key != nil {
+			p.redactor = &redactor{key: key}
+		}
+	}
+}
+
+// WithOutputVersion pins the shape of JSON output to a version with fixed
+// schemas. The default is LatestOutputVersion.
+func WithOutputVersion(version string) PrinterOption {
+	return func(p *Printer) {
+		if version != "" {
+			p.version = version
+		}
+	}
+}
+
+// NewPrinter validates format and any options, so that mistakes are
+// reported before a command makes API requests. Fields named by columns
+// and sorting depend on the printed value; see Check.
+func NewPrinter(format string, opts ...PrinterOption) (*Printer, error) {
+	p := &Printer{format: format, colorMode: ColorAuto, version: LatestOutputVersion}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	if err := validateColorMode(p.colorMode); err != nil {
+		return nil, err
+	}
+	if err := validateOutputVersion(p.version); err != nil {
+		return nil, err
+	}
+	if p.timezone != "" {
+		loc, err := time.LoadLocation(p.timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", p.timezone, err)
+		}
+		p.opts.location = loc
+	}
+	if len(p.opts.columns) > 0 && !IsTableFormat(format) {
+		return nil, fmt.Errorf("columns can only be chosen for text, wide, csv, tsv, markdown and html output")
+	}
+	if p.opts.noHeaders && (!IsTableFormat(format) || format == FormatMarkdown) {
+		return nil, fmt.Errorf("headers can only be omitted for text, wide, csv, tsv and html output")
+	}
+	if p.query != "" {
+		code, err := compileQuery(p.query)
+		if err != nil {
+			return nil, err
+		}
+		p.code = code
+	}
+	return p, nil
+}
+
+// Check reports a CSV or TSV format for samples without a tabular form,
+// and columns and a sort field that none of samples has, as *OptionError,
+// so that a command can reject them before making requests. samples are
+// values of the types the command may print. Nothing is checked with a
+// query, whose results have another shape. Columns and the sort field are
+// not checked when a sample has no tabular form.
+func (p *Printer) Check(samples ...interface{}) error {
+	if len(samples) == 0 || p.code != nil {
+		return nil
+	}
+	if p.format == FormatCSV || p.format == FormatTSV {
+		for _, sample := range samples {
+			if _, ok := buildTable(sample); !ok {
+				return unsupportedFormatError(p.format)
+			}
+		}
+	}
+	if len(p.opts.columns) == 0 && p.opts.sortBy == "" {
+		return nil
+	}
+	known := &table{}
+	list := false
+	for _, sample := range samples {
+		t, ok := buildTable(sample)
+		if !ok {
+			return nil
+		}
+		known.fields = appendMissing(known.fields, t.fields)
+		list = list || reflect.ValueOf(deref(sample)).Kind() == reflect.Slice
+	}
+
+	if _, err := selectColumns(known, p.opts.columns); err != nil {
+		return err
+	}
+	if p.opts.sortBy != "" && list {
+		if _, err := selectColumns(known, []string{strings.TrimPrefix(p.opts.sortBy, "-")}); err != nil {
+			return fmt.Errorf("cannot sort: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *Printer) Format() string {
+	return p.format
+}
+
+// OutputVersion returns the version of the JSON output shape.
+func (p *Printer) OutputVersion() string {
+	return p.version
+}
+
+// Print writes v to stdout, through a pager if enabled and the text output
+// is longer than the terminal.
+func (p *Printer) Print(v interface{}) error {
+	if !p.pager || (p.format != FormatText && p.format != FormatWide) || !isTerminal(os.Stdout) {
+		return p.Fprint(os.Stdout, v)
+	}
+
+	var buf bytes.Buffer
+	if err := p.render(&buf, os.Stdout, v); err != nil {
+		return err
+	}
+	return page(os.Stdout, buf.Bytes())
+}
+
+func (p *Printer) Fprint(w io.Writer, v interface{}) error {
+	return p.render(w, w, v)
+}
+
+// render writes v to w, sizing and coloring tables for display on
+// terminal, which differs from w when output is buffered for a pager.
+func (p *Printer) render(w, terminal io.Writer, v interface{}) error {
+	opts := p.opts
+	opts.width = terminalWidth(terminal)
+	opts.color = useColor(p.colorMode, terminal)
+
+	if p.redactor != nil {
+		v = p.redactor.redact(v)
+	}
+
+	if opts.sortBy != "" {
+		sorted, err := sortValue(v, opts.sortBy)
+		if err != nil {
+			return err
+		}
+		v = sorted
+	}
+	if p.code == nil {
+		return fprint(w, p.format, v, opts)
+	}
+	results, err := p.code.run(v)
+	if err != nil {
+		return err
+	}
+	return printQueryResults(w, p.format, results, opts)
+}
+
+// Preview returns a copy of p that prints plain text tables, for showing
+// what a command is about to change whatever the output format.
+func (p *Printer) Preview() *Printer {
+	preview := *p
+	if preview.format != FormatWide {
+		preview.format = FormatText
+	}
+	preview.query, preview.code = "", nil
+	preview.pager = false
+	preview.opts.columns = nil
+	preview.opts.sortBy = ""
+	preview.opts.noHeaders = false
+	return &preview
+}
+
+// Redact returns s, an email address or phone number, as printed results
+// show it: as its pseudonym if redaction is enabled, or unchanged. It is
+// for values in messages, which are not printed through p.
+func (p *Printer) Redact(s string) string {
+	if p.redactor == nil || s == "" {
+		return s
+	}
+	return p.redactor.pseudonym(s)
+}
+
+// LineWriter returns a writer for printing list items as they arrive. It
+// reports false unless the format is JSON Lines and no query or sort needs
+// to see the complete value.
+func (p *Printer) LineWriter(w io.Writer) (*LineWriter, bool) {
+	if !IsLineFormat(p.format) || p.code != nil || p.opts.sortBy != "" {
+		return nil, false
+	}
+	return p.JSONLineWriter(w), true
+}
+
+// JSONLineWriter returns a writer for commands whose output is always JSON
+// Lines, whatever the format.
+func (p *Printer) JSONLineWriter(w io.Writer) *LineWriter {
+	lw := NewLineWriter(w)
+	lw.redactor = p.redactor
+	return lw
+}
@@ -0,0 +1,182 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinter_Query(t *testing.T) {
+	masks := []CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{ID: 1, FullAddress: "a@mozmail.com", Enabled: true}},
+		{Type: "random", Mask: &api.RelayAddress{ID: 2, FullAddress: "b@mozmail.com"}},
+		{Type: "custom", Mask: api.DomainAddress{ID: 3, FullAddress: "shop@me.mozmail.com", Enabled: true}},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		query  string
+		want   string
+	}{
+		{
+			name:   "text prints raw strings",
+			format: FormatText,
+			query:  ".[] | select(.mask.enabled) | .mask.full_address",
+			want:   "a@mozmail.com\nshop@me.mozmail.com\n",
+		},
+		{
+			name:   "wide prints raw strings like text",
+			format: FormatWide,
+			query:  ".[0].mask.full_address",
+			want:   "a@mozmail.com\n",
+		},
+		{
+			name:   "text prints other values as json",
+			format: FormatText,
+			query:  ".[0].mask | {id, enabled}",
+			want:   "{\n  \"enabled\": true,\n  \"id\": 1\n}\n",
+		},
+		{
+			name:   "json collects several results",
+			format: FormatJSON,
+			query:  ".[].mask.id",
+			want:   "[\n  1,\n  2,\n  3\n]\n",
+		},
+		{
+			name:   "json prints a single result as is",
+			format: FormatJSON,
+			query:  "length",
+			want:   "3\n",
+		},
+		{
+			name:   "jsonl prints one result per line",
+			format: FormatJSONL,
+			query:  ".[] | {id: .mask.id}",
+			want:   "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n",
+		},
+		{
+			name:   "csv",
+			format: FormatCSV,
+			query:  "[.[] | {id: .mask.id, type}]",
+			want:   "id,type\n1,random\n2,random\n3,custom\n",
+		},
+		{
+			name:   "no results",
+			format: FormatText,
+			query:  ".[] | select(.type == \"none\")",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPrinter(tt.format, WithQuery(tt.query))
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, p.Fprint(&buf, masks))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestPrinter_InvalidQuery(t *testing.T) {
+	_, err := NewPrinter(FormatText, WithQuery(".["))
+	assert.ErrorContains(t, err, "invalid query")
+
+	p, err := NewPrinter(FormatText, WithQuery(`error("boom")`))
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Fprint(&bytes.Buffer{}, []int{}), "boom")
+}
+
+func TestPrinter_LineWriter(t *testing.T) {
+	p, err := NewPrinter(FormatJSONL)
+	require.NoError(t, err)
+	_, ok := p.LineWriter(&bytes.Buffer{})
+	assert.True(t, ok)
+
+	p, err = NewPrinter(FormatJSONL, WithQuery(".[0]"))
+	require.NoError(t, err)
+	_, ok = p.LineWriter(&bytes.Buffer{})
+	assert.False(t, ok, "a query needs the complete list")
+
+	p, err = NewPrinter(FormatJSON)
+	require.NoError(t, err)
+	_, ok = p.LineWriter(&bytes.Buffer{})
+	assert.False(t, ok)
+}
+
+func TestPrinter_Preview(t *testing.T) {
+	p, err := NewPrinter(FormatJSON, WithQuery(".[0].id"), WithColor(ColorNever))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Preview().Fprint(&buf, []BulkResult{{Type: "random", ID: 7, FullAddress: "a@mozmail.com", Status: "updated"}}))
+	assert.Contains(t, buf.String(), "STATUS")
+	assert.Contains(t, buf.String(), "a@mozmail.com")
+	assert.Equal(t, FormatJSON, p.Format(), "the original printer is unchanged")
+}
+
+func TestPrinter_Check(t *testing.T) {
+	samples := []interface{}{[]api.RelayAddress{}, []api.DomainAddress{}}
+	tests := []struct {
+		name    string
+		opts    []PrinterOption
+		samples []interface{}
+		wantErr string
+	}{
+		{"no options", nil, samples, ""},
+		{"known columns", []PrinterOption{WithColumns([]string{"id", "used_on", "domain"})}, samples, ""},
+		{"known sort field", []PrinterOption{WithSortBy("-num_forwarded")}, samples, ""},
+		{"unknown column", []PrinterOption{WithColumns([]string{"id", "bogus"})}, samples, `unknown field "bogus"`},
+		{"unknown sort field", []PrinterOption{WithSortBy("-bogus")}, samples, `cannot sort: unknown field "bogus"`},
+		{"sorting a single value", []PrinterOption{WithSortBy("bogus")}, []interface{}{api.RelayAddress{}}, ""},
+		{"query", []PrinterOption{WithQuery(".[]"), WithColumns([]string{"bogus"})}, samples, ""},
+		{"no samples", []PrinterOption{WithColumns([]string{"bogus"})}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPrinter(FormatText, tt.opts...)
+			require.NoError(t, err)
+			err = p.Check(tt.samples...)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			var optErr *OptionError
+			assert.ErrorAs(t, err, &optErr)
+		})
+	}
+}
+
+func TestPrinter_CheckDelimited(t *testing.T) {
+	export := AccountExport{}
+	for _, format := range []string{FormatCSV, FormatTSV} {
+		p, err := NewPrinter(format)
+		require.NoError(t, err)
+		assert.NoError(t, p.Check([]api.RelayAddress{}, api.RelayAddress{}))
+
+		err = p.Check(export)
+		assert.EqualError(t, err, format+" output is not supported for this command")
+		var optErr *OptionError
+		assert.ErrorAs(t, err, &optErr)
+	}
+
+	p, err := NewPrinter(FormatCSV, WithQuery(".masks"))
+	require.NoError(t, err)
+	assert.NoError(t, p.Check(export), "a query changes the shape of the output")
+
+	p, err = NewPrinter(FormatJSON)
+	require.NoError(t, err)
+	assert.NoError(t, p.Check(export))
+}
+
+func TestNewPrinter_InvalidFormat(t *testing.T) {
+	_, err := NewPrinter("xml")
+	assert.ErrorContains(t, err, "invalid output format")
+}
@@ -0,0 +1,86 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+type queryCode struct {
+	code *gojq.Code
+}
+
+func compileQuery(query string) (*queryCode, error) {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return &queryCode{code: code}, nil
+}
+
+// run evaluates the query against the JSON representation of v and returns
+// every value it emits.
+func (q *queryCode) run(v interface{}) ([]interface{}, error) {
+	input, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []interface{}{}
+	iter := q.code.Run(input)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			if halt, ok := err.(*gojq.HaltError); ok && halt.Value() == nil {
+				break
+			}
+			return nil, fmt.Errorf("query failed: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// printQueryResults prints the values emitted by a query. Text and wide
+// output behave like "jq -r": one value per line with strings unquoted. JSON Lines
+// writes one value per line. Other formats print a single result as is and
+// several results as a list.
+func printQueryResults(w io.Writer, format string, results []interface{}, opts printOptions) error {
+	switch {
+	case format == FormatText || format == FormatWide:
+		for _, result := range results {
+			if err := printRaw(w, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	case IsLineFormat(format):
+		lw := NewLineWriter(w)
+		for _, result := range results {
+			if err := lw.Write(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	case len(results) == 1:
+		return fprint(w, format, results[0], opts)
+	default:
+		return fprint(w, format, results, opts)
+	}
+}
+
+func printRaw(w io.Writer, v interface{}) error {
+	if s, ok := v.(string); ok {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+	return printJSON(w, v)
+}
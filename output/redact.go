@@ -0,0 +1,157 @@
+package output
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+)
+
+// redactedFields are the JSON fields holding email addresses, phone numbers
+// and the profile subdomain. address is included because it is the local
+// part of full_address.
+var redactedFields = map[string]bool{
+	"full_address":   true,
+	"address":        true,
+	"number":         true,
+	"inbound_number": true,
+	"real_num":       true,
+	"email":          true,
+	"subdomain":      true,
+}
+
+// Kinds of redacted values. The kind is part of the hashed input, so equal
+// text in different places, such as an email's local part and a subdomain,
+// gets unrelated pseudonyms.
+const (
+	kindLocalPart = "local"
+	kindDomain    = "domain"
+	kindSubdomain = "subdomain"
+	kindValue     = "value"
+)
+
+// fieldKinds are the kinds of redactedFields that hold part of an email
+// address rather than a whole value.
+var fieldKinds = map[string]string{
+	"address":   kindLocalPart,
+	"subdomain": kindSubdomain,
+}
+
+// relayDomains are shared by all users, so they are kept in redacted
+// addresses.
+var relayDomains = []string{"mozmail.com", "relay.firefox.com"}
+
+// redactor replaces personal values with pseudonyms derived from a keyed
+// hash. The same value always gets the same pseudonym, so relationships
+// such as a mask's address and full_address, or a profile's subdomain and
+// its custom domain masks, are preserved. The key keeps pseudonyms of
+// phone numbers from being reversed by hashing every possible number.
+type redactor struct {
+	key []byte
+}
+
+// redact returns a copy of v with the values of redactedFields replaced.
+// Struct types are preserved, so redacted values print like the originals
+// in every format.
+func (r *redactor) redact(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return r.value(reflect.ValueOf(v), "").Interface()
+}
+
+func (r *redactor) value(v reflect.Value, field string) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(r.value(v.Elem(), field))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(r.value(v.Elem(), field))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				out.Field(i).Set(r.value(v.Field(i), jsonName(f)))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.value(v.Index(i), field))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := ""
+			if iter.Key().Kind() == reflect.String {
+				name = iter.Key().String()
+			}
+			out.SetMapIndex(iter.Key(), r.value(iter.Value(), name))
+		}
+		return out
+	case reflect.String:
+		if !redactedFields[field] || v.String() == "" {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(r.field(field, v.String()))
+		return out
+	}
+	return v
+}
+
+// field redacts the value of a redacted field. A field holding part of an
+// email address gets the pseudonym of that part.
+func (r *redactor) field(name, s string) string {
+	if kind, ok := fieldKinds[name]; ok && !strings.Contains(s, "@") {
+		return r.hash(kind, s)
+	}
+	return r.pseudonym(s)
+}
+
+// pseudonym redacts an email address part by part, keeping relay domains,
+// and any other value as a whole. Subdomains of relay domains get the same
+// pseudonym as the profile's subdomain field.
+func (r *redactor) pseudonym(s string) string {
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok {
+		return r.hash(kindValue, s)
+	}
+	for _, relay := range relayDomains {
+		if domain == relay {
+			return r.hash(kindLocalPart, local) + "@" + domain
+		}
+		if sub, ok := strings.CutSuffix(domain, "."+relay); ok {
+			return r.hash(kindLocalPart, local) + "@" + r.hash(kindSubdomain, sub) + "." + relay
+		}
+	}
+	return r.hash(kindLocalPart, local) + "@" + r.hash(kindDomain, domain) + ".invalid"
+}
+
+func (r *redactor) hash(kind, s string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(s)))
+	return "redacted-" + hex.EncodeToString(mac.Sum(nil))[:10]
+}
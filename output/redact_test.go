@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_PreservesRelationships(t *testing.T) {
+	r := &redactor{key: []byte("test")}
+	subdomain := "alice"
+	realNum := "+14155550100"
+	export := AccountExport{
+		Masks: []CombinedMask{
+			{Type: "random", Mask: api.RelayAddress{ID: 1, Address: "abc123", FullAddress: "abc123@mozmail.com", Description: "shop"}},
+			{Type: "custom", Mask: &api.DomainAddress{ID: 2, Address: "shop", FullAddress: "shop@alice.mozmail.com"}},
+		},
+		Phones:   []api.RelayNumber{{ID: 3, Number: "+14155550123"}},
+		Profiles: []api.Profile{{ID: 4, Subdomain: &subdomain}},
+		Contacts: []api.InboundContact{{ID: 5, InboundNumber: "+14155550199"}},
+		Users:    []api.User{{Email: "Alice@Example.com"}},
+	}
+
+	got := r.redact(export).(AccountExport)
+
+	random := got.Masks[0].Mask.(api.RelayAddress)
+	assert.Equal(t, r.hash(kindLocalPart, "abc123"), random.Address)
+	assert.Equal(t, r.hash(kindLocalPart, "abc123")+"@mozmail.com", random.FullAddress)
+	assert.Equal(t, "shop", random.Description)
+
+	custom := got.Masks[1].Mask.(*api.DomainAddress)
+	assert.Equal(t, r.hash(kindLocalPart, "shop")+"@"+r.hash(kindSubdomain, "alice")+".mozmail.com", custom.FullAddress)
+	assert.Equal(t, r.hash(kindSubdomain, "alice"), *got.Profiles[0].Subdomain)
+
+	assert.Equal(t, r.hash(kindValue, "+14155550123"), got.Phones[0].Number)
+	assert.Equal(t, r.hash(kindValue, "+14155550199"), got.Contacts[0].InboundNumber)
+	assert.Equal(t, r.hash(kindLocalPart, "alice")+"@"+r.hash(kindDomain, "example.com")+".invalid", got.Users[0].Email)
+	assert.NotContains(t, got.Users[0].Email, *got.Profiles[0].Subdomain, "a local part and a subdomain with the same text get different pseudonyms")
+
+	suggestions := r.redact(api.RelayNumberSuggestions{RealNum: &realNum}).(api.RelayNumberSuggestions)
+	assert.Equal(t, r.hash(kindValue, "+14155550100"), *suggestions.RealNum)
+
+	// The original is left untouched.
+	assert.Equal(t, "abc123@mozmail.com", export.Masks[0].Mask.(api.RelayAddress).FullAddress)
+	assert.Equal(t, "alice", subdomain)
+}
+
+func TestRedactor_Maps(t *testing.T) {
+	r := &redactor{key: []byte("test")}
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(`[{"id": 1, "number": "+14155550123", "enabled": true}]`), &v))
+
+	got := r.redact(v).([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, r.hash(kindValue, "+14155550123"), got["number"])
+	assert.Equal(t, true, got["enabled"])
+}
+
+func TestRedactor_KeyChangesPseudonyms(t *testing.T) {
+	a := &redactor{key: []byte("a")}
+	b := &redactor{key: []byte("b")}
+	assert.Equal(t, a.hash(kindValue, "+14155550123"), a.hash(kindValue, "+14155550123"))
+	assert.NotEqual(t, a.hash(kindValue, "+14155550123"), b.hash(kindValue, "+14155550123"))
+}
+
+func TestPrinter_Redaction(t *testing.T) {
+	masks := []api.RelayAddress{{ID: 1, Address: "abc123", FullAddress: "abc123@mozmail.com", Enabled: true, CreatedAt: "2025-01-01T00:00:00Z"}}
+
+	for _, format := range []string{FormatText, FormatJSON, FormatCSV, FormatHTML} {
+		t.Run(format, func(t *testing.T) {
+			out := printWith(t, format, masks, WithRedaction([]byte("test")))
+			assert.NotContains(t, out, "abc123")
+			assert.Contains(t, out, "@mozmail.com")
+		})
+	}
+
+	t.Run("jsonl streaming", func(t *testing.T) {
+		p, err := NewPrinter(FormatJSONL, WithRedaction([]byte("test")))
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		lw, ok := p.LineWriter(&buf)
+		require.True(t, ok)
+		require.NoError(t, lw.Write(masks[0]))
+		assert.NotContains(t, buf.String(), "abc123")
+	})
+}
+
+func TestPrinter_Redact(t *testing.T) {
+	p, err := NewPrinter(FormatText, WithRedaction([]byte("test")))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, p.Fprint(&buf, []api.RelayAddress{{FullAddress: "abc123@mozmail.com"}}))
+	redacted := p.Redact("abc123@mozmail.com")
+	assert.NotContains(t, redacted, "abc123")
+	assert.Contains(t, buf.String(), redacted, "messages use the pseudonyms of printed results")
+
+	p, err = NewPrinter(FormatText)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123@mozmail.com", p.Redact("abc123@mozmail.com"))
+}
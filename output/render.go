@@ -0,0 +1,147 @@
+package output
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/hastefuI/ffrelayctl/api"
+)
+
+type renderer func(w io.Writer, v interface{}, opts printOptions) error
+
+// textRenderers maps value types to their text renderer. Pointers are
+// dereferenced before lookup, so registering T also covers *T.
+var textRenderers = make(map[reflect.Type]renderer)
+
+// listViews maps list types to the columns of their text table.
+var listViews = make(map[reflect.Type]listView)
+
+func register[T any](fn func(io.Writer, T, printOptions) error) {
+	textRenderers[typeOf[T]()] = func(w io.Writer, v interface{}, opts printOptions) error {
+		return fn(w, v.(T), opts)
+	}
+}
+
+func registerView[T any](view listView) {
+	listViews[typeOf[T]()] = view
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+var (
+	maskColumns     = []string{"id", "full_address", "enabled", "description", "num_forwarded", "num_blocked", "created_at", "last_used_at"}
+	maskWideColumns = []string{"used_on", "num_replied", "num_spam"}
+)
+
+func init() {
+	registerView[[]api.User](listView{
+		columns: []string{"email"},
+		empty:   "No users found.",
+	})
+	registerView[[]api.Profile](listView{
+		columns: []string{"id", "subdomain", "has_premium", "has_phone", "emails_forwarded", "emails_blocked", "emails_replied"},
+		wide:    []string{"level_one_trackers_blocked", "at_mask_limit", "date_subscribed"},
+		empty:   "No profiles found.",
+	})
+	registerView[[]CombinedMask](listView{
+		columns: []string{"id", "type", "full_address", "enabled", "description", "num_forwarded", "num_blocked", "created_at", "last_used_at"},
+		wide:    append([]string{"generated_for"}, maskWideColumns...),
+		empty:   "No masks found.",
+	})
+	registerView[[]api.RelayAddress](listView{
+		columns: maskColumns,
+		wide:    append([]string{"generated_for"}, maskWideColumns...),
+		empty:   "No random masks found.",
+	})
+	registerView[[]api.DomainAddress](listView{
+		columns: maskColumns,
+		wide:    maskWideColumns,
+		empty:   "No custom domain masks found.",
+	})
+	registerView[[]api.RelayNumber](listView{
+		columns: []string{"id", "number", "enabled", "location", "remaining_texts", "remaining_minutes"},
+		wide:    []string{"country_code", "created_at", "calls_forwarded", "calls_blocked", "texts_forwarded", "texts_blocked"},
+		empty:   "No phone masks found.",
+	})
+	registerView[[]api.InboundContact](listView{
+		columns: []string{"id", "inbound_number", "blocked", "num_calls", "num_texts", "last_inbound_date"},
+		wide:    []string{"last_inbound_type", "num_calls_blocked", "num_texts_blocked", "relay_number"},
+		empty:   "No inbound contacts found.",
+	})
+	registerView[[]api.RealPhone](listView{
+		columns: []string{"id", "number", "verified", "country_code"},
+		wide:    []string{"verification_sent_date", "verified_date"},
+		empty:   "No forwarding numbers found.",
+	})
+	registerView[[]BulkResult](listView{
+		columns: []string{"type", "id", "full_address", "status", "error"},
+		empty:   "No masks selected.",
+	})
+	registerView[[]SchemaInfo](listView{
+		columns: []string{"name", "title", "description"},
+		empty:   "No schemas found.",
+	})
+	registerView[[]api.PhoneNumberOption](listView{
+		columns: []string{"phone_number", "locality", "region", "iso_country"},
+		wide:    []string{"friendly_name", "postal_code"},
+		empty:   "No phone numbers found.",
+	})
+	registerView[[]ContextInfo](listView{
+		columns: []string{"current", "name", "base_url", "output", "timeout", "key_source"},
+		empty:   "No contexts found.",
+		cells:   map[string]func(interface{}) string{"current": formatCurrent},
+	})
+	register(printRelayNumberSuggestions)
+
+	register(describe[api.User])
+	register(describe[api.Profile])
+	register(describe[api.RelayAddress])
+	register(describe[api.DomainAddress])
+	register(describe[api.RelayNumber])
+	register(describe[api.InboundContact])
+	register(describe[api.RealPhone])
+	register(describe[api.PhoneNumberOption])
+	register(describe[CombinedMask])
+	register(describe[AuthStatus])
+	register(describe[ContextInfo])
+}
+
+func printText(w io.Writer, v interface{}, opts printOptions) error {
+	value := deref(v)
+	if value == nil {
+		return printJSON(w, v)
+	}
+
+	t := reflect.TypeOf(value)
+	if view, ok := listViews[t]; ok {
+		return printListView(w, value, view, opts)
+	}
+	// Other values, such as single objects, are shown as a table when
+	// columns are chosen.
+	if len(opts.columns) > 0 {
+		if tbl, ok := buildTable(value); ok {
+			return writeTextTable(w, tbl, opts.columns, nil, opts)
+		}
+	}
+	if render, ok := textRenderers[t]; ok {
+		return render(w, value, opts)
+	}
+	return printJSON(w, v)
+}
+
+// deref follows pointers in v, returning nil for a nil pointer.
+func deref(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
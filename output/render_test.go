@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintText_PointerUsesDetailView(t *testing.T) {
+	now = func() time.Time { return time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	lastUsed := "2025-01-02T00:00:00Z"
+	address := &api.RelayAddress{
+		ID:           12345,
+		FullAddress:  "abc123@mozmail.com",
+		Enabled:      true,
+		Description:  "Shopping\nand more",
+		GeneratedFor: "amazon.com",
+		LastUsedAt:   &lastUsed,
+		NumSpam:      3,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatText, address))
+
+	out := buf.String()
+	assert.NotContains(t, out, "{")
+	assert.Contains(t, out, "ID:")
+	assert.Contains(t, out, "abc123@mozmail.com")
+	assert.Contains(t, out, "Generated For:")
+	assert.Contains(t, out, "Used On:            -")
+	assert.Contains(t, out, "Last Used At:       3 days ago (")
+	assert.Contains(t, out, "Created At:         -")
+	assert.Contains(t, out, "Num Spam:           3")
+	assert.Contains(t, out, "\n                    and more\n")
+}
+
+func TestPrintText_ValueAndPointerMatch(t *testing.T) {
+	number := api.RelayNumber{ID: 1, Number: "+15551234567", Location: "Seattle"}
+
+	var fromValue, fromPointer bytes.Buffer
+	require.NoError(t, Fprint(&fromValue, FormatText, number))
+	require.NoError(t, Fprint(&fromPointer, FormatText, &number))
+
+	assert.Equal(t, fromValue.String(), fromPointer.String())
+}
+
+func TestPrintText_ProfileBounceStatus(t *testing.T) {
+	profile := api.Profile{ID: 1, BounceStatus: api.BounceStatus{Paused: true, Type: "soft"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatText, &profile))
+
+	assert.Contains(t, buf.String(), "Bounce Status:")
+	assert.Contains(t, buf.String(), "paused (soft bounce)")
+}
+
+func TestPrintText_CombinedMaskPointers(t *testing.T) {
+	masks := []CombinedMask{
+		{Type: "random", Mask: &api.RelayAddress{ID: 1, FullAddress: "a@mozmail.com"}},
+		{Type: "custom", Mask: api.DomainAddress{ID: 2, FullAddress: "b@me.mozmail.com"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatText, masks))
+
+	assert.Contains(t, buf.String(), "a@mozmail.com")
+	assert.Contains(t, buf.String(), "b@me.mozmail.com")
+}
+
+func TestPrintText_UnknownTypeFallsBackToJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatText, map[string]int{"count": 1}))
+
+	assert.JSONEq(t, `{"count": 1}`, buf.String())
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hastefuI/ffrelayctl/api"
+)
+
+// AccountExport is the complete account snapshot written by the export
+// command. SchemaVersion is the output version the snapshot conforms to.
+type AccountExport struct {
+	SchemaVersion string               `json:"schema_version"`
+	Masks         []CombinedMask       `json:"masks"`
+	Phones        []api.RelayNumber    `json:"phones"`
+	Profiles      []api.Profile        `json:"profiles"`
+	Contacts      []api.InboundContact `json:"contacts"`
+	Users         []api.User           `json:"users"`
+}
+
+var (
+	randomMaskReportColumns = []string{"id", "full_address", "enabled", "description", "generated_for", "num_forwarded", "num_blocked", "created_at", "last_used_at"}
+	customMaskReportColumns = []string{"id", "full_address", "enabled", "description", "used_on", "num_forwarded", "num_blocked", "created_at", "last_used_at"}
+	profileReportFields     = []string{"subdomain", "has_premium", "has_phone", "date_subscribed", "emails_forwarded", "emails_blocked", "emails_replied", "level_one_trackers_blocked", "at_mask_limit"}
+)
+
+// accountReport summarizes an export for people: overall statistics,
+// profile details, and tables of masks grouped by type, phone masks and
+// inbound contacts.
+func accountReport(export AccountExport, opts printOptions) (*document, error) {
+	doc := &document{
+		Title:     "Firefox Relay account report",
+		Heading:   "Firefox Relay account report",
+		Generated: now().In(opts.loc()).Format(absoluteTimeLayout),
+	}
+
+	var random, custom []CombinedMask
+	for _, m := range export.Masks {
+		if m.Type == "custom" {
+			custom = append(custom, m)
+		} else {
+			random = append(random, m)
+		}
+	}
+
+	doc.Sections = append(doc.Sections, section{Title: "Summary", Table: reportSummary(export, random, custom)})
+
+	for i, profile := range export.Profiles {
+		title := "Profile"
+		if len(export.Profiles) > 1 {
+			title = fmt.Sprintf("Profile %d", i+1)
+		}
+		s, err := profileSection(title, profile, opts)
+		if err != nil {
+			return nil, err
+		}
+		doc.Sections = append(doc.Sections, s)
+	}
+
+	sections := []struct {
+		title   string
+		value   interface{}
+		columns []string
+		empty   string
+	}{
+		{"Random masks", random, randomMaskReportColumns, "No random masks."},
+		{"Custom domain masks", custom, customMaskReportColumns, "No custom domain masks."},
+		{"Phone masks", export.Phones, listViews[typeOf[[]api.RelayNumber]()].columns, "No phone masks."},
+		{"Inbound contacts", export.Contacts, listViews[typeOf[[]api.InboundContact]()].columns, "No inbound contacts."},
+	}
+	for _, s := range sections {
+		t, _ := buildTable(s.value)
+		if t == nil || len(t.rows) == 0 {
+			doc.Sections = append(doc.Sections, section{Title: s.title, Empty: s.empty})
+			continue
+		}
+		rt, err := renderTable(t, s.columns, nil, opts)
+		if err != nil {
+			return nil, err
+		}
+		doc.Sections = append(doc.Sections, section{Title: s.title, Table: rt})
+	}
+	return doc, nil
+}
+
+func reportSummary(export AccountExport, random, custom []CombinedMask) *renderedTable {
+	var emails []string
+	for _, u := range export.Users {
+		emails = append(emails, u.Email)
+	}
+
+	blocked := 0
+	for _, c := range export.Contacts {
+		if c.Blocked {
+			blocked++
+		}
+	}
+
+	var forwarded, blockedEmails, replied, trackers int
+	for _, p := range export.Profiles {
+		forwarded += p.EmailsForwarded
+		blockedEmails += p.EmailsBlocked
+		replied += p.EmailsReplied
+		trackers += p.LevelOneTrackersBlocked
+	}
+
+	return &renderedTable{
+		Headers: []string{"METRIC", "VALUE"},
+		Rows: [][]string{
+			{"Account", orDash(strings.Join(emails, ", "))},
+			{"Random masks", maskCounts(random)},
+			{"Custom domain masks", maskCounts(custom)},
+			{"Phone masks", strconv.Itoa(len(export.Phones))},
+			{"Inbound contacts", fmt.Sprintf("%d (%d blocked)", len(export.Contacts), blocked)},
+			{"Emails forwarded", strconv.Itoa(forwarded)},
+			{"Emails blocked", strconv.Itoa(blockedEmails)},
+			{"Emails replied", strconv.Itoa(replied)},
+			{"Trackers blocked", strconv.Itoa(trackers)},
+		},
+	}
+}
+
+func maskCounts(masks []CombinedMask) string {
+	enabled := 0
+	for _, m := range masks {
+		switch mask := deref(m.Mask).(type) {
+		case api.RelayAddress:
+			if mask.Enabled {
+				enabled++
+			}
+		case api.DomainAddress:
+			if mask.Enabled {
+				enabled++
+			}
+		}
+	}
+	return fmt.Sprintf("%d (%d enabled, %d disabled)", len(masks), enabled, len(masks)-enabled)
+}
+
+func profileSection(title string, profile api.Profile, opts printOptions) (section, error) {
+	t, _ := buildTable(profile)
+	indexes, err := selectColumns(t, profileReportFields)
+	if err != nil {
+		return section{}, err
+	}
+
+	rt := &renderedTable{Headers: []string{"FIELD", "VALUE"}}
+	for i, name := range profileReportFields {
+		rt.Rows = append(rt.Rows, []string{fieldLabel(name), opts.textCell(name, t.rows[0][indexes[i]])})
+	}
+	return section{Title: title, Table: rt}, nil
+}
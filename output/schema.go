@@ -0,0 +1,88 @@
+package output
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// LatestOutputVersion is the newest shape of JSON output. Each version has
+// a frozen set of JSON Schemas; changing the shape of any output requires a
+// new version, so tools that pin a version with --output-version keep
+// working.
+const LatestOutputVersion = "1"
+
+//go:embed schemas
+var schemaFiles embed.FS
+
+// SchemaInfo describes one of the JSON Schemas of an output version.
+type SchemaInfo struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func OutputVersions() []string {
+	return []string{"1"}
+}
+
+func validateOutputVersion(version string) error {
+	for _, v := range OutputVersions() {
+		if v == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output version %q: must be one of [%s]", version, strings.Join(OutputVersions(), "|"))
+}
+
+// Schemas lists the JSON Schemas of an output version by name.
+func Schemas(version string) ([]SchemaInfo, error) {
+	if err := validateOutputVersion(version); err != nil {
+		return nil, err
+	}
+	entries, err := schemaFiles.ReadDir(schemaDir(version))
+	if err != nil {
+		return nil, err
+	}
+
+	var schemas []SchemaInfo
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		data, err := Schema(version, name)
+		if err != nil {
+			return nil, err
+		}
+		info := SchemaInfo{Name: name}
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %w", name, err)
+		}
+		info.Name = name
+		schemas = append(schemas, info)
+	}
+	return schemas, nil
+}
+
+// Schema returns the JSON Schema document with the given name.
+func Schema(version, name string) ([]byte, error) {
+	if err := validateOutputVersion(version); err != nil {
+		return nil, err
+	}
+	data, err := schemaFiles.ReadFile(path.Join(schemaDir(version), name+".json"))
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) || strings.Contains(name, "/") {
+		schemas, _ := Schemas(version)
+		names := make([]string, 0, len(schemas))
+		for _, s := range schemas {
+			names = append(names, s.Name)
+		}
+		return nil, fmt.Errorf("unknown schema %q, available schemas: %s", name, strings.Join(names, ", "))
+	}
+	return data, err
+}
+
+func schemaDir(version string) string {
+	return path.Join("schemas", "v"+version)
+}
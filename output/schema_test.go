@@ -0,0 +1,110 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSchema struct {
+	Properties map[string]struct {
+		Type interface{} `json:"type"`
+		Ref  string      `json:"$ref"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+// TestSchemas_MatchTypes fails when a printed type no longer matches the
+// latest schemas. Published versions are frozen: rather than editing their
+// schemas, add a new output version.
+func TestSchemas_MatchTypes(t *testing.T) {
+	types := map[string]reflect.Type{
+		"auth-status":         typeOf[AuthStatus](),
+		"batch-result":        typeOf[BatchResult](),
+		"bulk-result":         typeOf[BulkResult](),
+		"contact":             typeOf[api.InboundContact](),
+		"context":             typeOf[ContextInfo](),
+		"custom-mask":         typeOf[api.DomainAddress](),
+		"export":              typeOf[AccountExport](),
+		"forwarding-number":   typeOf[api.RealPhone](),
+		"mask":                typeOf[CombinedMask](),
+		"phone-mask":          typeOf[api.RelayNumber](),
+		"phone-number-option": typeOf[api.PhoneNumberOption](),
+		"phone-suggestions":   typeOf[api.RelayNumberSuggestions](),
+		"profile":             typeOf[api.Profile](),
+		"random-mask":         typeOf[api.RelayAddress](),
+		"user":                typeOf[api.User](),
+	}
+
+	schemas, err := Schemas(LatestOutputVersion)
+	require.NoError(t, err)
+	require.Len(t, schemas, len(types))
+
+	for _, info := range schemas {
+		t.Run(info.Name, func(t *testing.T) {
+			typ, ok := types[info.Name]
+			require.True(t, ok, "no type for schema")
+			assert.NotEmpty(t, info.Title)
+			assert.NotEmpty(t, info.Description)
+
+			data, err := Schema(LatestOutputVersion, info.Name)
+			require.NoError(t, err)
+			var schema testSchema
+			require.NoError(t, json.Unmarshal(data, &schema))
+
+			fields := fieldNames(typ)
+			assert.Equal(t, fields, schema.Required)
+			assert.Len(t, schema.Properties, len(fields))
+			for i, name := range fields {
+				prop, ok := schema.Properties[name]
+				if !assert.True(t, ok, "missing property %s", name) {
+					continue
+				}
+				if prop.Type != nil {
+					assert.Equal(t, jsonSchemaType(typ.Field(i).Type), prop.Type, name)
+				}
+			}
+		})
+	}
+}
+
+func jsonSchemaType(t reflect.Type) interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return []interface{}{jsonSchemaType(t.Elem()), "null"}
+	case reflect.String:
+		return "string"
+	case reflect.Int:
+		return "integer"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "array"
+	case reflect.Struct:
+		if t == typeOf[api.BounceStatus]() {
+			return "array"
+		}
+	}
+	return "object"
+}
+
+func TestSchema_Unknown(t *testing.T) {
+	_, err := Schema(LatestOutputVersion, "nope")
+	assert.ErrorContains(t, err, `unknown schema "nope", available schemas: auth-status, batch-result, bulk-result, contact`)
+
+	_, err = Schema(LatestOutputVersion, "../v1/mask")
+	assert.ErrorContains(t, err, "unknown schema")
+}
+
+func TestNewPrinter_OutputVersion(t *testing.T) {
+	p, err := NewPrinter(FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, LatestOutputVersion, p.OutputVersion())
+
+	_, err = NewPrinter(FormatJSON, WithOutputVersion("0"))
+	assert.EqualError(t, err, `unsupported output version "0": must be one of [1]`)
+}
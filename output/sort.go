@@ -0,0 +1,104 @@
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sortValue returns a copy of the list v ordered by a field of its table,
+// descending if the field is prefixed with "-". Null values sort last in
+// either direction. Values other than lists are returned unchanged.
+func sortValue(v interface{}, field string) (interface{}, error) {
+	rv := reflect.ValueOf(deref(v))
+	if rv.Kind() != reflect.Slice {
+		return v, nil
+	}
+	t, ok := buildTable(v)
+	if !ok || len(t.rows) != rv.Len() {
+		return nil, &OptionError{fmt.Errorf("sorting is not supported for this command")}
+	}
+
+	descending := strings.HasPrefix(field, "-")
+	field = strings.TrimPrefix(field, "-")
+	indexes, err := selectColumns(t, []string{field})
+	if err != nil {
+		return nil, fmt.Errorf("cannot sort: %w", err)
+	}
+	col := indexes[0]
+
+	order := make([]int, rv.Len())
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := t.rows[order[i]][col], t.rows[order[j]][col]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		if descending {
+			return compareValues(b, a) < 0
+		}
+		return compareValues(a, b) < 0
+	})
+
+	sorted := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	for i, idx := range order {
+		sorted.Index(i).Set(rv.Index(idx))
+	}
+	return sorted.Interface(), nil
+}
+
+// compareValues orders numbers numerically, timestamps chronologically,
+// strings case-insensitively and false before true.
+func compareValues(a, b interface{}) int {
+	if x, ok := numberValue(a); ok {
+		if y, ok := numberValue(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+
+	x, y := formatCell(a), formatCell(b)
+	if tx, err := time.Parse(time.RFC3339, x); err == nil {
+		if ty, err := time.Parse(time.RFC3339, y); err == nil {
+			return tx.Compare(ty)
+		}
+	}
+	if c := strings.Compare(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+		return c
+	}
+	return strings.Compare(x, y)
+}
+
+func numberValue(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
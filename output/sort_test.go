@@ -0,0 +1,67 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func maskIDs(t *testing.T, v interface{}) []int {
+	t.Helper()
+	var ids []int
+	for _, m := range v.([]CombinedMask) {
+		switch mask := deref(m.Mask).(type) {
+		case api.RelayAddress:
+			ids = append(ids, mask.ID)
+		case api.DomainAddress:
+			ids = append(ids, mask.ID)
+		}
+	}
+	return ids
+}
+
+func TestSortValue(t *testing.T) {
+	lastUsed := "2024-05-01T00:00:00Z"
+	masks := []CombinedMask{
+		{Type: "random", Mask: api.RelayAddress{ID: 1, Description: "beta", NumForwarded: 5, CreatedAt: "2024-01-01T10:00:00+02:00"}},
+		{Type: "custom", Mask: &api.DomainAddress{ID: 2, Description: "Alpha", NumForwarded: 40, CreatedAt: "2024-01-01T09:00:00Z", LastUsedAt: &lastUsed}},
+		{Type: "random", Mask: api.RelayAddress{ID: 3, Description: "gamma", NumForwarded: 12, CreatedAt: "2023-12-31T00:00:00Z"}},
+	}
+
+	tests := []struct {
+		field string
+		want  []int
+	}{
+		{field: "num_forwarded", want: []int{1, 3, 2}},
+		{field: "-num_forwarded", want: []int{2, 3, 1}},
+		{field: "description", want: []int{2, 1, 3}},
+		{field: "created_at", want: []int{3, 1, 2}},
+		{field: "-last_used_at", want: []int{2, 1, 3}},
+		{field: "type", want: []int{2, 1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			sorted, err := sortValue(masks, tt.field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, maskIDs(t, sorted))
+		})
+	}
+	assert.Equal(t, []int{1, 2, 3}, maskIDs(t, masks), "the input is not modified")
+}
+
+func TestSortValue_UnknownField(t *testing.T) {
+	_, err := sortValue([]api.RelayNumber{{ID: 1}}, "nope")
+	assert.ErrorContains(t, err, `unknown field "nope"`)
+	var optErr *OptionError
+	assert.ErrorAs(t, err, &optErr)
+}
+
+func TestSortValue_SingleObjectUnchanged(t *testing.T) {
+	mask := &api.RelayAddress{ID: 1}
+	sorted, err := sortValue(mask, "id")
+	require.NoError(t, err)
+	assert.Same(t, mask, sorted)
+}
@@ -0,0 +1,205 @@
+package output
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/hastefuI/ffrelayctl/api"
+)
+
+// table is a flattened, format-independent view of a list of objects. Each
+// field is named after its JSON key; cells hold dereferenced Go values, or
+// nil when a value is null or the row's type has no such field.
+type table struct {
+	fields []string
+	rows   [][]interface{}
+}
+
+type suggestionCategory struct {
+	name    string
+	options []api.PhoneNumberOption
+}
+
+// buildTable flattens v into a table. It reports false for values that have
+// no tabular form, such as the export document.
+func buildTable(v interface{}) (*table, bool) {
+	v = deref(v)
+	if v == nil {
+		return nil, false
+	}
+
+	switch data := v.(type) {
+	case []CombinedMask:
+		return combinedMaskTable(data), true
+	case CombinedMask:
+		return combinedMaskTable([]CombinedMask{data}), true
+	case api.RelayNumberSuggestions:
+		return suggestionsTable(data), true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Struct:
+		if !isFlatStruct(rv.Type()) {
+			return nil, false
+		}
+		t := &table{fields: fieldNames(rv.Type())}
+		t.rows = append(t.rows, structRow(rv))
+		return t, true
+	case reflect.Slice:
+		elem := rv.Type().Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		switch {
+		case elem.Kind() == reflect.Struct && isFlatStruct(elem):
+			t := &table{fields: fieldNames(elem)}
+			for i := 0; i < rv.Len(); i++ {
+				item := reflect.Indirect(rv.Index(i))
+				if !item.IsValid() {
+					continue
+				}
+				t.rows = append(t.rows, structRow(item))
+			}
+			return t, true
+		case elem.Kind() == reflect.Map || elem.Kind() == reflect.Interface:
+			return mapTable(rv)
+		}
+	}
+	return nil, false
+}
+
+func combinedMaskTable(masks []CombinedMask) *table {
+	fields := []string{"type"}
+	fields = appendMissing(fields, fieldNames(reflect.TypeOf(api.RelayAddress{})))
+	fields = appendMissing(fields, fieldNames(reflect.TypeOf(api.DomainAddress{})))
+
+	t := &table{fields: fields}
+	for _, m := range masks {
+		values := map[string]interface{}{"type": m.Type}
+		if mask := deref(m.Mask); mask != nil {
+			rv := reflect.ValueOf(mask)
+			if rv.Kind() == reflect.Struct {
+				row := structRow(rv)
+				for i, name := range fieldNames(rv.Type()) {
+					values[name] = row[i]
+				}
+			}
+		}
+		t.rows = append(t.rows, rowFromMap(fields, values))
+	}
+	return t
+}
+
+func suggestionsTable(suggestions api.RelayNumberSuggestions) *table {
+	optionType := reflect.TypeOf(api.PhoneNumberOption{})
+	t := &table{fields: append([]string{"category"}, fieldNames(optionType)...)}
+
+	categories := []suggestionCategory{
+		{"same_prefix", suggestions.SamePrefixOptions},
+		{"same_area", suggestions.SameAreaOptions},
+		{"other_areas", suggestions.OtherAreasOptions},
+		{"random", suggestions.RandomOptions},
+	}
+	for _, category := range categories {
+		for _, option := range category.options {
+			row := append([]interface{}{category.name}, structRow(reflect.ValueOf(option))...)
+			t.rows = append(t.rows, row)
+		}
+	}
+	return t
+}
+
+// mapTable handles decoded JSON such as the api command's responses. Fields
+// are the union of all keys, in sorted order per object as first seen.
+func mapTable(rv reflect.Value) (*table, bool) {
+	var fields []string
+	objects := make([]map[string]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		obj, ok := deref(rv.Index(i).Interface()).(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields = appendMissing(fields, keys)
+		objects = append(objects, obj)
+	}
+
+	t := &table{fields: fields}
+	for _, obj := range objects {
+		t.rows = append(t.rows, rowFromMap(fields, obj))
+	}
+	return t, true
+}
+
+func rowFromMap(fields []string, values map[string]interface{}) []interface{} {
+	row := make([]interface{}, len(fields))
+	for i, name := range fields {
+		row[i] = values[name]
+	}
+	return row
+}
+
+func appendMissing(fields []string, names []string) []string {
+	seen := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		seen[name] = true
+	}
+	for _, name := range names {
+		if !seen[name] {
+			fields = append(fields, name)
+			seen[name] = true
+		}
+	}
+	return fields
+}
+
+func fieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if name := jsonName(field); name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func structRow(rv reflect.Value) []interface{} {
+	var row []interface{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() || jsonName(field) == "-" {
+			continue
+		}
+		row = append(row, deref(rv.Field(i).Interface()))
+	}
+	return row
+}
+
+// isFlatStruct reports whether every field of t fits in a single cell.
+func isFlatStruct(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Interface:
+			return false
+		case reflect.Struct:
+			if !isScalarStruct(ft) {
+				return false
+			}
+		}
+	}
+	return true
+}
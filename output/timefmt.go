@@ -0,0 +1,92 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Embed the time zone database so that time zones can be loaded on
+	// systems without one, such as Windows and minimal containers.
+	_ "time/tzdata"
+)
+
+const absoluteTimeLayout = "2006-01-02 15:04 MST"
+
+var now = time.Now
+
+// isTimeField reports whether a JSON field holds an RFC 3339 timestamp,
+// e.g. created_at, last_inbound_date or date_subscribed.
+func isTimeField(name string) bool {
+	return strings.HasSuffix(name, "_at") || strings.HasSuffix(name, "_date") || strings.HasPrefix(name, "date_")
+}
+
+func (o printOptions) loc() *time.Location {
+	if o.location == nil {
+		return time.Local
+	}
+	return o.location
+}
+
+// formatTime renders a timestamp for people: relative to now, such as
+// "3 days ago", or in the configured time zone with absolute times. Values
+// that are not timestamps are returned unchanged.
+func (o printOptions) formatTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	if o.absoluteTimes {
+		return t.In(o.loc()).Format(absoluteTimeLayout)
+	}
+	return relativeTime(t, now())
+}
+
+// formatTimeDetail renders a timestamp for detail views, which have room
+// for both forms, e.g. "3 days ago (2024-05-01 10:00 CEST)".
+func (o printOptions) formatTimeDetail(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	absolute := t.In(o.loc()).Format(absoluteTimeLayout)
+	if o.absoluteTimes {
+		return absolute
+	}
+	return fmt.Sprintf("%s (%s)", relativeTime(t, now()), absolute)
+}
+
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var amount string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount = plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		amount = plural(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		amount = plural(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		amount = plural(int(d/(30*24*time.Hour)), "month")
+	default:
+		amount = plural(int(d/(365*24*time.Hour)), "year")
+	}
+
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelativeTime(t *testing.T) {
+	base := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{ago: 10 * time.Second, want: "just now"},
+		{ago: time.Minute, want: "1 minute ago"},
+		{ago: 45 * time.Minute, want: "45 minutes ago"},
+		{ago: 5 * time.Hour, want: "5 hours ago"},
+		{ago: 3 * 24 * time.Hour, want: "3 days ago"},
+		{ago: 65 * 24 * time.Hour, want: "2 months ago"},
+		{ago: 800 * 24 * time.Hour, want: "2 years ago"},
+		{ago: -2 * 24 * time.Hour, want: "in 2 days"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, relativeTime(base.Add(-tt.ago), base), tt.ago.String())
+	}
+}
+
+func TestTextTimes(t *testing.T) {
+	now = func() time.Time { return time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	contacts := []api.InboundContact{{ID: 1, LastInboundDate: "2025-06-14T22:30:00Z"}}
+	columns := WithColumns([]string{"id", "last_inbound_date"})
+
+	out := printWith(t, FormatText, contacts, columns)
+	assert.Equal(t, "ID  LAST CONTACT\n1   13 hours ago\n", out)
+
+	out = printWith(t, FormatText, contacts, columns, WithAbsoluteTimes(true), WithTimezone("Asia/Tokyo"))
+	assert.Equal(t, "ID  LAST CONTACT\n1   2025-06-15 07:30 JST\n", out)
+
+	out = printWith(t, FormatCSV, contacts, columns, WithTimezone("Asia/Tokyo"))
+	assert.Equal(t, "id,last_inbound_date\n1,2025-06-14T22:30:00Z\n", out, "machine formats keep raw timestamps")
+}
+
+func TestNewPrinter_InvalidTimezone(t *testing.T) {
+	_, err := NewPrinter(FormatText, WithTimezone("Mars/Olympus_Mons"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timezone")
+}
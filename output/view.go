@@ -0,0 +1,313 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+const (
+	columnGap      = 2
+	minColumnWidth = 8
+
+	// freeTextWidth caps free-text columns when the terminal width is
+	// unknown, e.g. when output is piped.
+	freeTextWidth = 30
+)
+
+// listView describes the text table for a list type. Columns are JSON field
+// names of the type's table (see buildTable).
+type listView struct {
+	columns []string
+	// wide lists the extra columns shown with -o wide.
+	wide  []string
+	empty string
+	cells map[string]func(interface{}) string
+}
+
+var headerOverrides = map[string]string{
+	"full_address":      "ADDRESS",
+	"created_at":        "CREATED",
+	"last_used_at":      "LAST USED",
+	"has_premium":       "PREMIUM",
+	"has_phone":         "PHONE",
+	"emails_forwarded":  "FORWARDED",
+	"emails_blocked":    "BLOCKED",
+	"emails_replied":    "REPLIED",
+	"num_forwarded":     "FORWARDED",
+	"num_blocked":       "BLOCKED",
+	"num_replied":       "REPLIED",
+	"num_spam":          "SPAM",
+	"remaining_texts":   "TEXTS LEFT",
+	"remaining_minutes": "MINS LEFT",
+	"inbound_number":    "NUMBER",
+	"num_calls":         "CALLS",
+	"num_texts":         "TEXTS",
+	"last_inbound_date": "LAST CONTACT",
+	"country_code":      "COUNTRY",
+	"iso_country":       "COUNTRY",
+	"phone_number":      "NUMBER",
+	"locality":          "LOCATION",
+}
+
+var freeTextFields = map[string]bool{
+	"description":   true,
+	"generated_for": true,
+	"used_on":       true,
+}
+
+func columnHeader(field string) string {
+	if header, ok := headerOverrides[field]; ok {
+		return header
+	}
+	return strings.ToUpper(strings.ReplaceAll(field, "_", " "))
+}
+
+func printListView(w io.Writer, v interface{}, view listView, opts printOptions) error {
+	t, ok := buildTable(v)
+	if !ok {
+		return printJSON(w, v)
+	}
+	if len(t.rows) == 0 {
+		fmt.Fprintln(w, view.empty)
+		return nil
+	}
+
+	return writeTextTable(w, t, view.columnsFor(opts), view.cells, opts)
+}
+
+// columnsFor returns the columns to show: those chosen by the user, or the
+// view's defaults plus its wide columns in wide mode.
+func (view listView) columnsFor(opts printOptions) []string {
+	switch {
+	case len(opts.columns) > 0:
+		return opts.columns
+	case opts.wide:
+		return append(append([]string{}, view.columns...), view.wide...)
+	}
+	return view.columns
+}
+
+// selectColumns returns the index in t of each named column.
+func selectColumns(t *table, columns []string) ([]int, error) {
+	indexes := make([]int, len(columns))
+	for i, name := range columns {
+		indexes[i] = -1
+		for j, field := range t.fields {
+			if field == name {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, &OptionError{fmt.Errorf("unknown field %q, available fields: %s", name, strings.Join(t.fields, ", "))}
+		}
+	}
+	return indexes, nil
+}
+
+// renderedTable holds the formatted cells of a table ready to print. Headers
+// is empty when headers are omitted. Styles are set only when color is
+// enabled.
+type renderedTable struct {
+	Headers []string
+	Rows    [][]string
+
+	rowStyles  []string
+	cellStyles [][]string
+}
+
+func renderTable(t *table, columns []string, cells map[string]func(interface{}) string, opts printOptions) (*renderedTable, error) {
+	indexes, err := selectColumns(t, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := &renderedTable{}
+	if !opts.noHeaders {
+		for _, name := range columns {
+			rt.Headers = append(rt.Headers, columnHeader(name))
+		}
+	}
+	for _, row := range t.rows {
+		line := make([]string, len(columns))
+		styles := make([]string, len(columns))
+		for i, name := range columns {
+			if format := cells[name]; format != nil {
+				line[i] = format(row[indexes[i]])
+			} else {
+				line[i] = opts.textCell(name, row[indexes[i]])
+			}
+			styles[i] = cellStyle(name, row[indexes[i]])
+		}
+		rt.Rows = append(rt.Rows, line)
+		if opts.color {
+			rt.rowStyles = append(rt.rowStyles, rowStyle(t, row))
+			rt.cellStyles = append(rt.cellStyles, styles)
+		}
+	}
+	return rt, nil
+}
+
+func writeTextTable(w io.Writer, t *table, columns []string, cells map[string]func(interface{}) string, opts printOptions) error {
+	rt, err := renderTable(t, columns, cells, opts)
+	if err != nil {
+		return err
+	}
+
+	widths := make([]int, len(columns))
+	for _, line := range append([][]string{rt.Headers}, rt.Rows...) {
+		for i, cell := range line {
+			widths[i] = max(widths[i], runewidth.StringWidth(cell))
+		}
+	}
+	if !opts.wide {
+		limitWidths(widths, columns, opts.width)
+	}
+
+	if rt.Headers != nil {
+		style := ""
+		if opts.color {
+			style = styleBold
+		}
+		if err := writeTextRow(w, rt.Headers, widths, style, nil); err != nil {
+			return err
+		}
+	}
+	for i, row := range rt.Rows {
+		var style string
+		var cellStyles []string
+		if opts.color {
+			style, cellStyles = rt.rowStyles[i], rt.cellStyles[i]
+		}
+		if err := writeTextRow(w, row, widths, style, cellStyles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTextRow pads and truncates each cell to its column width. Styles are
+// applied around the padded text so they do not affect alignment.
+func writeTextRow(w io.Writer, cells []string, widths []int, style string, cellStyles []string) error {
+	var sb strings.Builder
+	sb.WriteString(style)
+	for i, cell := range cells {
+		cell = truncateWidth(cell, widths[i])
+		if i < len(cellStyles) && cellStyles[i] != "" {
+			sb.WriteString(cellStyles[i] + cell + styleReset + style)
+		} else {
+			sb.WriteString(cell)
+		}
+		if i < len(cells)-1 {
+			sb.WriteString(strings.Repeat(" ", widths[i]-runewidth.StringWidth(cell)+columnGap))
+		}
+	}
+	line := strings.TrimRight(sb.String(), " ")
+	if style != "" {
+		line += styleReset
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// limitWidths narrows columns so a row fits in termWidth, shrinking the
+// widest free-text column first and then the widest of the rest. When the
+// width is unknown, only free-text columns are capped.
+func limitWidths(widths []int, columns []string, termWidth int) {
+	if termWidth <= 0 {
+		for i, name := range columns {
+			if freeTextFields[name] {
+				widths[i] = min(widths[i], freeTextWidth)
+			}
+		}
+		return
+	}
+
+	excess := columnGap*(len(widths)-1) - termWidth
+	for _, width := range widths {
+		excess += width
+	}
+	for _, freeTextOnly := range []bool{true, false} {
+		for excess > 0 {
+			widest := -1
+			for i, name := range columns {
+				if freeTextOnly && !freeTextFields[name] || widths[i] <= minColumnWidth {
+					continue
+				}
+				if widest < 0 || widths[i] > widths[widest] {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			widths[widest]--
+			excess--
+		}
+	}
+}
+
+// truncateWidth shortens s to at most width terminal cells, measuring wide
+// and combining characters correctly.
+func truncateWidth(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return runewidth.Truncate(s, width, "")
+	}
+	return runewidth.Truncate(s, width, "...")
+}
+
+// terminalWidth returns the width of w if it is a terminal, or the COLUMNS
+// environment variable if set. It returns 0 when the width is unknown.
+func terminalWidth(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if !isTerminal(w) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(w.(*os.File).Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// textCell renders the value of field for people, showing timestamps as
+// relative or zoned times.
+func (o printOptions) textCell(field string, v interface{}) string {
+	if s, ok := v.(string); ok && s != "" && isTimeField(field) {
+		return o.formatTime(s)
+	}
+	return formatTextCell(v)
+}
+
+// formatTextCell renders a table cell for people: null and empty values are
+// shown as "-" and line breaks are flattened.
+func formatTextCell(v interface{}) string {
+	s := formatCell(v)
+	if s == "" {
+		return "-"
+	}
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ").Replace(s)
+}
+
+func formatCurrent(v interface{}) string {
+	if current, ok := v.(bool); ok && current {
+		return "*"
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var viewMasks = []api.RelayAddress{
+	{ID: 1, FullAddress: "a@mozmail.com", Enabled: true, Description: "Café newsletters and receipts from 東京の店舗", NumForwarded: 10, CreatedAt: "2024-01-01T00:00:00Z"},
+	{ID: 2, FullAddress: "b@mozmail.com", NumForwarded: 3, CreatedAt: "2023-06-01T00:00:00Z"},
+}
+
+func printWith(t *testing.T, format string, v interface{}, opts ...PrinterOption) string {
+	t.Helper()
+	p, err := NewPrinter(format, opts...)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Fprint(&buf, v))
+	return buf.String()
+}
+
+func TestTextTable_DefaultColumns(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	lines := strings.Split(strings.TrimSuffix(printWith(t, FormatText, viewMasks), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ID", "ADDRESS", "ENABLED", "DESCRIPTION", "FORWARDED", "BLOCKED", "CREATED", "LAST", "USED"}, strings.Fields(lines[0]))
+	assert.Contains(t, lines[2], " - ", "empty description is shown as a dash")
+}
+
+func TestTextTable_TruncatesByDisplayWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	out := printWith(t, FormatText, viewMasks, WithColumns([]string{"description"}), WithNoHeaders(true))
+	first := strings.Split(out, "\n")[0]
+	assert.Equal(t, freeTextWidth, runewidth.StringWidth(first))
+	assert.True(t, strings.HasSuffix(first, "..."))
+	assert.True(t, strings.HasPrefix(first, "Café"), "multi-byte characters are kept intact")
+}
+
+func TestTextTable_FitsTerminalWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "90")
+	out := printWith(t, FormatText, viewMasks)
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		assert.LessOrEqual(t, runewidth.StringWidth(line), 90, line)
+	}
+	assert.Contains(t, out, "a@mozmail.com", "short columns are not truncated before free text")
+}
+
+func TestTextTable_Wide(t *testing.T) {
+	t.Setenv("COLUMNS", "40")
+	out := printWith(t, FormatWide, viewMasks)
+	assert.Contains(t, out, "GENERATED FOR")
+	assert.Contains(t, out, "東京の店舗", "wide output is never truncated")
+}
+
+func TestTextTable_ColumnsAndNoHeaders(t *testing.T) {
+	out := printWith(t, FormatText, viewMasks,
+		WithColumns([]string{"full_address", "created_at"}),
+		WithNoHeaders(true),
+		WithAbsoluteTimes(true),
+		WithTimezone("UTC"),
+	)
+	assert.Equal(t, "a@mozmail.com  2024-01-01 00:00 UTC\nb@mozmail.com  2023-06-01 00:00 UTC\n", out)
+
+	out = printWith(t, FormatCSV, viewMasks, WithColumns([]string{"id", "num_forwarded"}))
+	assert.Equal(t, "id,num_forwarded\n1,10\n2,3\n", out)
+}
+
+func TestTextTable_ColumnsOnSingleObject(t *testing.T) {
+	out := printWith(t, FormatText, &viewMasks[0], WithColumns([]string{"id", "full_address"}))
+	assert.Equal(t, "ID  ADDRESS\n1   a@mozmail.com\n", out)
+}
+
+func TestTextTable_UnknownColumn(t *testing.T) {
+	p, err := NewPrinter(FormatText, WithColumns([]string{"nope"}))
+	require.NoError(t, err)
+	err = p.Fprint(&bytes.Buffer{}, viewMasks)
+	assert.ErrorContains(t, err, `unknown field "nope"`)
+	assert.ErrorContains(t, err, "full_address")
+	var optErr *OptionError
+	assert.ErrorAs(t, err, &optErr)
+}
+
+func TestNewPrinter_ColumnsRequireTableFormat(t *testing.T) {
+	_, err := NewPrinter(FormatJSON, WithColumns([]string{"id"}))
+	assert.Error(t, err)
+	_, err = NewPrinter(FormatYAML, WithNoHeaders(true))
+	assert.Error(t, err)
+}
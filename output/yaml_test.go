@@ -0,0 +1,61 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hastefuI/ffrelayctl/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPrintYAML_FieldOrderAndNames(t *testing.T) {
+	mask := CombinedMask{Type: "random", Mask: api.RelayAddress{
+		ID:          1,
+		FullAddress: "abc@mozmail.com",
+		Description: "true",
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Fprint(&buf, FormatYAML, []CombinedMask{mask}))
+
+	out := buf.String()
+	assert.Contains(t, out, "- type: random\n  mask:\n    id: 1\n    address: \"\"\n")
+	assert.Contains(t, out, "full_address: abc@mozmail.com")
+	assert.Contains(t, out, "description: \"true\"")
+	assert.Contains(t, out, "last_used_at: null")
+}
+
+func TestPrintYAML_RoundTripsLikeJSON(t *testing.T) {
+	locality := "Tyler"
+	profile := api.Profile{
+		ID:           7,
+		BounceStatus: api.BounceStatus{Paused: true, Type: "hard"},
+	}
+	option := api.PhoneNumberOption{PhoneNumber: "+14305550101", Locality: &locality}
+
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "profile", v: profile},
+		{name: "phone number option", v: []api.PhoneNumberOption{option}},
+		{name: "multi-line description", v: api.DomainAddress{Description: "line one\nline two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var yamlOut, jsonOut bytes.Buffer
+			require.NoError(t, Fprint(&yamlOut, FormatYAML, tt.v))
+			require.NoError(t, Fprint(&jsonOut, FormatJSON, tt.v))
+
+			var fromYAML interface{}
+			require.NoError(t, yaml.Unmarshal(yamlOut.Bytes(), &fromYAML))
+			var fromJSON interface{}
+			require.NoError(t, yaml.Unmarshal(jsonOut.Bytes(), &fromJSON))
+
+			assert.Equal(t, fromJSON, fromYAML)
+		})
+	}
+}